@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"railguard/internal/adapter/signature"
 	"railguard/internal/adapter/storage/sqlite"
 	"railguard/internal/core/services"
 	"railguard/internal/ui"
@@ -17,15 +18,17 @@ func main() {
 	myApp := app.NewWithID("com.ramin.railguard")
 
 	// 2. Database Path Logic (Android/Desktop)
-	var dbPath string
+	var dbPath, keyDir string
 	storageRoot := myApp.Storage().RootURI()
 
 	if storageRoot != nil && storageRoot.Scheme() == "file" {
 		// Android / Mobile path
 		dbPath = filepath.Join(storageRoot.Path(), "railguard.db")
+		keyDir = filepath.Join(storageRoot.Path(), "keys")
 	} else {
 		// Desktop path
 		dbPath = "./railguard.db"
+		keyDir = "./keys"
 	}
 
 	// 3. Initialize Repositories
@@ -37,6 +40,21 @@ func main() {
 
 	ruleRepo := sqlite.NewRuleRepository(dbPath)
 
+	licenseRepo, err := sqlite.NewLicenseRepository(dbPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize License Repository: %v", err)
+	}
+
+	defectRepo, err := sqlite.NewDefectRepository(dbPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Defect Repository: %v", err)
+	}
+
+	goodsRepo, err := sqlite.NewDangerousGoodsRepository(dbPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Dangerous Goods Repository: %v", err)
+	}
+
 	// 4. Initialize Services
 	brakeCalculator := services.NewBrakeCalculatorService(ruleRepo)
 	safetyValidator, err := services.NewSafetyValidatorService(ruleRepo)
@@ -44,13 +62,19 @@ func main() {
 		log.Fatalf("Failed to initialize Safety Validator: %v", err)
 	}
 
-	// 5. Initialize UI
-	application := ui.NewApp(wagonRepo, brakeCalculator, safetyValidator)
+	// 5. Load (or create on first run) the examiner's signing key
+	signer, err := signature.LoadOrCreateSigner(keyDir)
+	if err != nil {
+		log.Fatalf("Failed to load signing key: %v", err)
+	}
+
+	// 6. Initialize UI
+	application := ui.NewApp(wagonRepo, licenseRepo, defectRepo, goodsRepo, brakeCalculator, safetyValidator, signer)
 
 	// Inject the app instance with the correct ID
 	application.FyneApp = myApp
 
-	// 6. Run
+	// 7. Run
 	if application.MainWindow == nil {
 		log.Fatal("Main Window is nil")
 	}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"railguard/internal/adapter/report"
+	"railguard/internal/adapter/signature"
+	"railguard/internal/core/domain"
+)
+
+const usage = `Usage:
+  license keygen [-dir ./keys]             Create a new examiner key pair
+  license verify [-pub key.pub] <file.pdf> Check a brake license for tampering
+  license read <file.pdf>                  Print the composition stored in a license`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "keygen":
+		keygen(os.Args[2:])
+	case "verify":
+		verify(os.Args[2:])
+	case "read":
+		read(os.Args[2:])
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+}
+
+func keygen(args []string) {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	dir := fs.String("dir", "./keys", "directory for the key files")
+	fs.Parse(args)
+
+	if _, err := os.Stat(signature.PublicKeyPath(*dir)); err == nil {
+		log.Fatalf("A key pair already exists in %s", *dir)
+	}
+
+	signer, err := signature.GenerateKeyPair(*dir)
+	if err != nil {
+		log.Fatalf("Key generation failed: %v", err)
+	}
+	fmt.Printf("Created key %s in %s\n", signer.KeyID(), *dir)
+}
+
+func verify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	pubPath := fs.String("pub", "", "trusted examiner public key (PEM); without it the signer is not verified")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	var trusted ed25519.PublicKey
+	if *pubPath != "" {
+		pub, err := signature.LoadPublicKey(*pubPath)
+		if err != nil {
+			log.Fatalf("Cannot load public key: %v", err)
+		}
+		trusted = pub
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("Cannot read license: %v", err)
+	}
+
+	res, err := report.VerifyLicense(data, trusted)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Key ID:       %s\n", res.KeyID)
+	fmt.Printf("Payload hash: %s\n", res.PayloadHash)
+	if res.Payload != nil {
+		fmt.Printf("Train No:     %s\n", res.Payload.Trip.TrainNumber)
+		fmt.Printf("Issued at:    %s\n", res.Payload.IssuedAt.Format("2006-01-02 15:04:05"))
+	}
+	if !res.OK() {
+		fmt.Println("\n❌ LICENSE NOT VERIFIED")
+		for _, p := range res.Problems {
+			fmt.Println(" -", p)
+		}
+		os.Exit(1)
+	}
+	fmt.Println("\n✅ License signature is valid.")
+}
+
+func read(args []string) {
+	if len(args) != 1 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		log.Fatalf("Cannot read license: %v", err)
+	}
+	p, err := report.ReadLicense(data)
+	if err != nil {
+		log.Fatalf("Cannot decode license: %v", err)
+	}
+
+	fmt.Printf("Train No: %s | %s -> %s\n", p.Trip.TrainNumber, p.Trip.Origin, p.Trip.Destination)
+	fmt.Printf("Driver: %s | Train Boss: %s\n", p.Trip.DriverName, p.Trip.TrainBossName)
+	fmt.Printf("Issued at: %s\n\n", p.IssuedAt.Format("2006-01-02 15:04:05"))
+
+	for _, l := range p.Train.Locomotives {
+		state := "hot"
+		if !l.IsHot {
+			state = "dead"
+		}
+		fmt.Printf("🚂 %-8s #%d  %v  (%s)\n", l.ID, l.Number, l.Weight, state)
+	}
+	for i, w := range p.Train.Wagons {
+		load := "empty"
+		if w.IsLoaded {
+			load = "loaded"
+		}
+		line := fmt.Sprintf("%3d. 🚃 %d  %s  %v / brake %v", i+1, w.WagonSpec.Number, load, w.EffectiveWeight, w.EffectiveBrakeWeight)
+		if w.HasDangerousGoods {
+			line += "  ⚠️ " + w.DangerousGoodsCode
+		}
+		fmt.Println(line)
+	}
+
+	fmt.Printf("\nWeight: %v | Brake: %v (%d %%) | Max Speed: %v | Safe: %v\n",
+		p.Train.TotalWeight, p.Train.TotalBrake, p.Result.BrakePercentage, p.Result.MaxSpeed, p.Result.IsSafe)
+
+	if err := domain.CheckVehicles(p.Train.Locomotives, p.Train.Wagons); err != nil {
+		fmt.Printf("\n❌ The composition is inconsistent:\n%v\n", err)
+		os.Exit(1)
+	}
+}
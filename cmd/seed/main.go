@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
 	"strconv"
 	"strings"
 
@@ -93,6 +95,31 @@ func importWagons(db *sql.DB) {
 		log.Fatalf("Cannot read rows: %v", err)
 	}
 
+	// wagon_specs keeps weights in tonnes and lengths in metres. A sheet giving
+	// another unit in its header, e.g. "Weight (kg)", is converted on the way in.
+	massUnit := func(idx int) units.Mass {
+		name := headerUnit(rows[0], idx)
+		if name == "" {
+			return units.Tonne
+		}
+		u, err := units.ParseMassUnit(name)
+		if err != nil {
+			log.Fatalf("Column %d: %v", idx+1, err)
+		}
+		return u
+	}
+	lengthUnit := func(idx int) units.Length {
+		name := headerUnit(rows[0], idx)
+		if name == "" {
+			return units.Metre
+		}
+		u, err := units.ParseLengthUnit(name)
+		if err != nil {
+			log.Fatalf("Column %d: %v", idx+1, err)
+		}
+		return u
+	}
+
 	tx, _ := db.Begin()
 	stmt, _ := tx.Prepare(`INSERT INTO wagon_specs 
 		(wagon_type, axis_count, start_number, end_number, brake_weight_empty, brake_weight_loaded, 
@@ -101,6 +128,7 @@ func importWagons(db *sql.DB) {
 	defer stmt.Close()
 
 	// Iterate rows (Skip header row 0)
+	imported, skipped := 0, 0
 	for i, row := range rows {
 		if i == 0 || len(row) < 10 {
 			continue 
@@ -132,21 +160,54 @@ func importWagons(db *sql.DB) {
 		// verify against your specific excel if numbers are 0.
 		// Based on csv snippet: Type(0), Axis(1), Start(2), End(3)...
 		// Let's assume standard order, update indices if needed after first run.
-		brakeEmpty := getFloat(16) 
-		brakeLoaded := getFloat(17)
-		wEmpty := getFloat(21)
-		wLoaded := getFloat(22)
-		length := getFloat(23)
-		capacity := getFloat(20)
-
-		_, err = stmt.Exec(wagonType, axisCount, startNum, endNum, brakeEmpty, brakeLoaded, wEmpty, wLoaded, length, capacity)
+		getMass := func(idx int) units.Mass { return units.Mass(getFloat(idx)) * massUnit(idx) }
+		getLength := func(idx int) units.Length { return units.Length(getFloat(idx)) * lengthUnit(idx) }
+		brakeEmpty := getMass(16)
+		brakeLoaded := getMass(17)
+		wEmpty := getMass(21)
+		wLoaded := getMass(22)
+		length := getLength(23)
+		capacity := getMass(20)
+
+		// Values far out of range were read in the wrong unit, e.g. kg under a header without one
+		spec := domain.Wagon{BrakeWeightEmpty: brakeEmpty, BrakeWeightLoaded: brakeLoaded,
+			WeightEmpty: wEmpty, WeightLoaded: wLoaded, Length: length, MaxCapacity: capacity}
+		if err := spec.CheckPlausible(); err != nil {
+			log.Printf("Skipping row %d (%s %d-%d): %v", i+1, wagonType, startNum, endNum, err)
+			skipped++
+			continue
+		}
+
+		_, err = stmt.Exec(wagonType, axisCount, startNum, endNum, brakeEmpty.Tonnes(), brakeLoaded.Tonnes(),
+			wEmpty.Tonnes(), wLoaded.Tonnes(), length.Metres(), capacity.Tonnes())
 		if err != nil {
 			log.Printf("Error inserting row %d: %v", i, err)
 		}
+		imported++
+	}
+	if imported == 0 && skipped > 0 {
+		log.Fatalf("No wagon row is plausible, check the units in the header of %s", wagonsFile)
+	}
+	if skipped > 0 {
+		fmt.Printf("Skipped %d implausible wagon rows, see the log above.\n", skipped)
 	}
 	tx.Commit()
 }
 
+// headerUnit returns the unit a header cell gives in brackets, e.g. "kg" for
+// "Weight (kg)", or "" when it gives none.
+func headerUnit(header []string, idx int) string {
+	if idx >= len(header) {
+		return ""
+	}
+	cell := header[idx]
+	open, close := strings.LastIndexAny(cell, "(["), strings.LastIndexAny(cell, ")]")
+	if open < 0 || close < open {
+		return ""
+	}
+	return cell[open+1 : close]
+}
+
 func importBrakeRules(db *sql.DB) {
 	fmt.Println("Importing Brake Rules...")
 	f, err := excelize.OpenFile(brakeFile)
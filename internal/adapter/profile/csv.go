@@ -0,0 +1,81 @@
+// Package profile reads route gradient profiles from files.
+package profile
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
+	"strconv"
+	"strings"
+)
+
+// ReadCSV reads a profile with one "chainage_km,gradient_permil" line per gradient
+// change, in the direction of travel. Falling gradients are positive. Lines starting
+// with # are skipped; the last line ends the route. An optional header may give the
+// chainage in another unit, e.g. "chainage_m".
+func ReadCSV(r io.Reader, name string) (*domain.GradientProfile, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	p := &domain.GradientProfile{Name: name}
+	chainageUnit := units.Kilometre
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected chainage and gradient", line)
+		}
+		chainage, errC := strconv.ParseFloat(strings.TrimSpace(record[0]), 64)
+		gradient, errG := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if errC != nil || errG != nil {
+			if first {
+				if chainageUnit, err = headerUnit(record[0]); err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid number in %q", line, strings.Join(record, ","))
+		}
+		p.Points = append(p.Points, domain.GradientPoint{
+			Chainage: units.Length(chainage) * chainageUnit,
+			Gradient: units.Permil(gradient),
+		})
+	}
+	if len(p.Points) == 0 {
+		return nil, errors.New("the file contains no gradient points")
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// headerUnit reads the unit from a chainage column name like "chainage_m" or
+// "Chainage (m)". A name not ending in a unit, e.g. "start_chainage", keeps the
+// default of km; only a bracketed unit that is not a length is an error.
+func headerUnit(column string) (units.Length, error) {
+	column = strings.TrimSpace(column)
+	i := strings.LastIndexAny(column, "_ (")
+	if i < 0 {
+		return units.Kilometre, nil
+	}
+	u, err := units.ParseLengthUnit(strings.Trim(column[i+1:], "()"))
+	if err != nil {
+		if strings.HasSuffix(column, ")") {
+			return 0, err
+		}
+		return units.Kilometre, nil
+	}
+	return u, nil
+}
@@ -0,0 +1,96 @@
+package report
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"railguard/internal/core/domain"
+	"strings"
+	"time"
+)
+
+// LicenseFormat identifies the machine-readable payload attached to a license PDF.
+const LicenseFormat = "railguard-license/1"
+
+// LicensePayload is the full composition and result behind a license.
+// It is attached to the PDF as license.json and is the data covered by the signature.
+type LicensePayload struct {
+	Format   string                    `json:"format"`
+	Trip     domain.TripInfo           `json:"trip"`
+	Train    *domain.Train             `json:"train"`
+	Result   *domain.CalculationResult `json:"result"`
+	IssuedAt time.Time                 `json:"issued_at"`
+}
+
+// ReadLicense extracts the attached payload from a license PDF. For a signed
+// license the signed data is read, and an attachment that differs from it is
+// refused as tampered with.
+func ReadLicense(pdfData []byte) (*LicensePayload, error) {
+	streams := pdfStreams(pdfData)
+	attached := findPayload(streams)
+	data := attached
+	if env, _ := findEnvelope(streams); env != nil {
+		if attached != nil && !bytes.Equal(attached, env.Payload) {
+			return nil, errors.New("attached license.json differs from the signed license data; the license has been altered")
+		}
+		data = env.Payload
+	}
+	if data == nil {
+		return nil, errors.New("no RailGuard license data found in this PDF")
+	}
+	var p LicensePayload
+	if err := json.Unmarshal(data, &p); err != nil || p.Format != LicenseFormat {
+		return nil, errors.New("license data is unreadable")
+	}
+	if p.Train == nil || p.Result == nil {
+		return nil, errors.New("license data is incomplete")
+	}
+	domain.ClearStaleGoods(p.Train.Wagons)
+	return &p, nil
+}
+
+// findPayload returns the raw license.json attachment, nil if there is none.
+func findPayload(streams [][]byte) []byte {
+	for _, s := range streams {
+		if !bytes.Contains(s, []byte(LicenseFormat)) {
+			continue
+		}
+		var p struct {
+			Format string `json:"format"`
+		}
+		if err := json.Unmarshal(s, &p); err == nil && p.Format == LicenseFormat {
+			return s
+		}
+	}
+	return nil
+}
+
+func payloadHash(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// qrSummary builds the compact text encoded in the license QR code:
+// RG1|train|date|locos|wagons|weight|brake%|speed|verdict|sha256 of license.json
+func qrSummary(p LicensePayload, hash string) string {
+	verdict := "UNSAFE"
+	if p.Result.IsSafe {
+		verdict = "SAFE"
+	}
+	fields := []string{
+		"RG1",
+		p.Trip.TrainNumber,
+		p.IssuedAt.Format("20060102T1504"),
+		fmt.Sprintf("L%d", len(p.Train.Locomotives)),
+		fmt.Sprintf("W%d", len(p.Train.Wagons)),
+		fmt.Sprintf("%.1ft", p.Train.TotalWeight.Tonnes()),
+		fmt.Sprintf("B%d", p.Result.BrakePercentage),
+		"V" + maxSpeedText(p.Result.MaxSpeed),
+		verdict,
+		hash,
+	}
+	return strings.Join(fields, "|")
+}
@@ -0,0 +1,189 @@
+package report
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"railguard/internal/adapter/signature"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
+	"strings"
+)
+
+// SignatureFormat identifies the signature envelope embedded in a license PDF.
+const SignatureFormat = "railguard-license-signature/1"
+
+// SignatureEnvelope is attached to the PDF as a JSON file.
+// Payload holds the exact bytes that were signed.
+type SignatureEnvelope struct {
+	Format    string `json:"format"`
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	PublicKey []byte `json:"public_key"`
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"`
+}
+
+// VerificationReport describes the outcome of VerifyLicense.
+type VerificationReport struct {
+	Payload        *LicensePayload
+	KeyID          string
+	PayloadHash    string
+	SignatureValid bool
+	KeyTrusted     bool
+	Problems       []string // Every detected sign of tampering, and an unverified signer
+}
+
+// OK reports whether the license is authentic and untouched.
+func (r *VerificationReport) OK() bool {
+	return r.SignatureValid && r.KeyTrusted && len(r.Problems) == 0
+}
+
+func signPayload(signer *signature.Signer, payload []byte) ([]byte, error) {
+	env := SignatureEnvelope{
+		Format:    SignatureFormat,
+		Algorithm: signature.Algorithm,
+		KeyID:     signer.KeyID(),
+		PublicKey: signer.PublicKey(),
+		Payload:   payload,
+		Signature: signer.Sign(payload),
+	}
+	return json.Marshal(env)
+}
+
+// findEnvelope returns the signature envelope of a license and its raw bytes,
+// nil if the license is unsigned.
+func findEnvelope(streams [][]byte) (*SignatureEnvelope, []byte) {
+	for _, s := range streams {
+		if !bytes.Contains(s, []byte(SignatureFormat)) {
+			continue
+		}
+		var env SignatureEnvelope
+		if err := json.Unmarshal(s, &env); err == nil && env.Format == SignatureFormat {
+			return &env, s
+		}
+	}
+	return nil, nil
+}
+
+// VerifyLicense checks a license PDF against its embedded signature.
+// If trusted is nil the integrity of the document is still checked, but the
+// signer is reported as not verified and the license is not OK: anyone can
+// sign a PDF with a key of their own.
+func VerifyLicense(pdfData []byte, trusted ed25519.PublicKey) (*VerificationReport, error) {
+	streams := pdfStreams(pdfData)
+
+	env, envelope := findEnvelope(streams)
+	if env == nil {
+		return nil, errors.New("license is not signed: no signature envelope found")
+	}
+	if env.Algorithm != signature.Algorithm {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", env.Algorithm)
+	}
+
+	report := &VerificationReport{
+		KeyID:       signature.KeyID(env.PublicKey),
+		PayloadHash: payloadHash(env.Payload),
+	}
+
+	// 1. Signature over the embedded data
+	report.SignatureValid = signature.Verify(env.PublicKey, env.Payload, env.Signature)
+	if !report.SignatureValid {
+		report.Problems = append(report.Problems, "signature does not match the embedded license data")
+	}
+	if report.KeyID != env.KeyID {
+		report.Problems = append(report.Problems, "key ID in the envelope does not match its public key")
+	}
+
+	// The attachment read by OPEN LICENSE must be the signed data itself
+	switch attached := findPayload(streams); {
+	case attached == nil:
+		report.Problems = append(report.Problems, "license.json attachment is missing")
+	case !bytes.Equal(attached, env.Payload):
+		report.Problems = append(report.Problems, "attached license.json differs from the signed license data")
+	}
+
+	// 2. Signer identity
+	report.KeyTrusted = trusted != nil && bytes.Equal(trusted, env.PublicKey)
+	switch {
+	case trusted == nil:
+		report.Problems = append(report.Problems, fmt.Sprintf("signer not verified: no trusted examiner key given for key %s", report.KeyID))
+	case !report.KeyTrusted:
+		report.Problems = append(report.Problems, fmt.Sprintf("signed by unknown key %s", report.KeyID))
+	}
+
+	var payload LicensePayload
+	if err := json.Unmarshal(env.Payload, &payload); err != nil {
+		report.Problems = append(report.Problems, "embedded license data is unreadable")
+		return report, nil
+	}
+	report.Payload = &payload
+	if payload.Result == nil || payload.Train == nil {
+		report.Problems = append(report.Problems, "embedded license data is incomplete")
+		return report, nil
+	}
+	// Stale codes of older licenses never counted and do not print, see ClearStaleGoods
+	domain.ClearStaleGoods(payload.Train.Wagons)
+	if err := domain.CheckVehicles(payload.Train.Locomotives, payload.Train.Wagons); err != nil {
+		report.Problems = append(report.Problems, "embedded train is inconsistent: "+strings.ReplaceAll(err.Error(), "\n", ", "))
+	}
+
+	// 3. The printed page must be exactly what the signed data renders to
+	var expected bytes.Buffer
+	if err := render(&expected, payload, env.Payload, envelope, false); err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("license cannot be rendered from its signed data: %v", err))
+		return report, nil
+	}
+	var printed []string
+	for _, t := range pdfTexts(streams) {
+		if t != duplicateMark && !strings.HasPrefix(t, reprintPrefix) {
+			printed = append(printed, t)
+		}
+	}
+	missing, extra := textDiff(pdfTexts(pdfStreams(expected.Bytes())), printed)
+	for _, t := range missing {
+		report.Problems = append(report.Problems, fmt.Sprintf("printed content differs from signed data (missing %q)", t))
+	}
+	for _, t := range extra {
+		report.Problems = append(report.Problems, fmt.Sprintf("printed content differs from signed data (unexpected %q)", t))
+	}
+
+	return report, nil
+}
+
+// textDiff compares two lists of printed texts, counting repeats.
+func textDiff(want, got []string) (missing, extra []string) {
+	left := make(map[string]int)
+	for _, t := range got {
+		left[t]++
+	}
+	for _, t := range want {
+		if left[t] > 0 {
+			left[t]--
+		} else {
+			missing = append(missing, t)
+		}
+	}
+	for _, t := range got {
+		if left[t] > 0 {
+			left[t]--
+			extra = append(extra, t)
+		}
+	}
+	return missing, extra
+}
+
+func hashLine(hash string) string {
+	return "Payload SHA-256: " + hash
+}
+
+// maxSpeedText is the max speed as printed in the summary table, in whole km/h
+func maxSpeedText(speed units.Speed) string {
+	return fmt.Sprintf("%.0f", speed.KmPerHour())
+}
+
+func brakePercentageLine(percentage int) string {
+	return fmt.Sprintf("Brake Percentage:    %d %%", percentage)
+}
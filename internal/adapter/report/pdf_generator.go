@@ -1,21 +1,90 @@
 package report
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"railguard/internal/adapter/signature"
 	"railguard/internal/core/domain"
 	"time"
 
 	"github.com/jung-kurt/gofpdf"
+	"github.com/skip2/go-qrcode"
 )
 
-type PDFGenerator struct{}
+type PDFGenerator struct {
+	signer *signature.Signer
+}
+
+// NewPDFGenerator creates a generator. If signer is nil the licenses are left unsigned.
+func NewPDFGenerator(signer *signature.Signer) *PDFGenerator {
+	return &PDFGenerator{signer: signer}
+}
 
-func NewPDFGenerator() *PDFGenerator {
-	return &PDFGenerator{}
+// LicenseFileName builds the file name for the seq-th license of a train on the issue day.
+func LicenseFileName(trainNumber string, issuedAt time.Time, seq int) string {
+	return fmt.Sprintf("BrakeLicense_%s_%s_%03d.pdf", trainNumber, issuedAt.Format("20060102"), seq)
 }
 
-// GenerateBrakeLicense creates a PDF file with the train safety report.
-func (g *PDFGenerator) GenerateBrakeLicense(train *domain.Train, res *domain.CalculationResult, info domain.TripInfo) error {
+// GenerateBrakeLicense writes the train safety report issued at issuedAt as a
+// PDF to out; the caller names and archives the file by the same time.
+// It returns the license.json payload and the signature.json envelope (nil when
+// unsigned) embedded in the document, for archiving.
+func (g *PDFGenerator) GenerateBrakeLicense(out io.Writer, train *domain.Train, res *domain.CalculationResult, info domain.TripInfo, issuedAt time.Time) (payload, envelope []byte, err error) {
+	license := LicensePayload{Format: LicenseFormat, Trip: info, Train: train, Result: res, IssuedAt: issuedAt}
+	payload, err = json.Marshal(license)
+	if err != nil {
+		return nil, nil, err
+	}
+	if g.signer != nil {
+		if envelope, err = signPayload(g.signer, payload); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := render(out, license, payload, envelope, false); err != nil {
+		return nil, nil, err
+	}
+	return payload, envelope, nil
+}
+
+// ReprintLicense renders an archived license again from its original payload,
+// watermarked as "DUPLICATE". Date, time and payload hash match the original,
+// and the original signature envelope is embedded unchanged, so the copy verifies
+// as issued by the original examiner. A license archived without its envelope
+// is reprinted unsigned; the loaded key never signs a duplicate.
+func (g *PDFGenerator) ReprintLicense(out io.Writer, payload, envelope []byte) error {
+	var license LicensePayload
+	if err := json.Unmarshal(payload, &license); err != nil {
+		return err
+	}
+	if license.Train == nil || license.Result == nil {
+		return errors.New("archived license data is incomplete")
+	}
+	if envelope != nil {
+		var env SignatureEnvelope
+		if err := json.Unmarshal(envelope, &env); err != nil {
+			return fmt.Errorf("archived signature is unreadable: %w", err)
+		}
+		if !bytes.Equal(env.Payload, payload) {
+			return errors.New("archived signature does not belong to this license")
+		}
+	}
+	return render(out, license, payload, envelope, true)
+}
+
+// render draws the license. Everything printed comes from license, payload and
+// envelope (nil = unsigned), so VerifyLicense can render it again and compare.
+func render(out io.Writer, license LicensePayload, payload, envelope []byte, duplicate bool) error {
+	train, res, info, issuedAt := license.Train, license.Result, license.Trip, license.IssuedAt
+	hash := payloadHash(payload)
+
+	qrPNG, err := qrcode.Encode(qrSummary(license, hash), qrcode.Medium, 256)
+	if err != nil {
+		return err
+	}
+
 	pdf := gofpdf.New("P", "mm", "A4", "")
 	pdf.AddPage()
 
@@ -26,7 +95,17 @@ func (g *PDFGenerator) GenerateBrakeLicense(train *domain.Train, res *domain.Cal
 
 	pdf.SetFont("Arial", "", 10)
 	pdf.CellFormat(190, 8, "Islamic Republic of Iran Railways", "0", 1, "C", false, 0, "")
-	pdf.Ln(10)
+
+	if duplicate {
+		drawDuplicateMark(pdf)
+		pdf.SetFont("Arial", "B", 10)
+		pdf.SetTextColor(255, 0, 0)
+		pdf.CellFormat(190, 6, reprintPrefix+time.Now().Format("2006-01-02 15:04"), "0", 1, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.Ln(4)
+	} else {
+		pdf.Ln(10)
+	}
 
 	// --- 2. Trip Information ---
 	// For simple key-values, we use simple Cells
@@ -35,12 +114,12 @@ func (g *PDFGenerator) GenerateBrakeLicense(train *domain.Train, res *domain.Cal
 	// Row 1
 	pdf.Cell(30, 8, "Date:")
 	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(60, 8, time.Now().Format("2006-01-02"))
+	pdf.Cell(60, 8, orDefault(info.Date, issuedAt.Format("2006-01-02")))
 
 	pdf.SetFont("Arial", "B", 10)
 	pdf.Cell(30, 8, "Time:")
 	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(60, 8, time.Now().Format("15:04:05"))
+	pdf.Cell(60, 8, orDefault(info.Time, issuedAt.Format("15:04:05")))
 	pdf.Ln(8)
 
 	// Row 2
@@ -104,12 +183,12 @@ func (g *PDFGenerator) GenerateBrakeLicense(train *domain.Train, res *domain.Cal
 	// CellFormat for Body (No Fill)
 	pdf.CellFormat(w[0], 12, fmt.Sprintf("%d", train.AxleCount), "1", 0, "C", false, 0, "")
 	pdf.CellFormat(w[1], 12, fmt.Sprintf("%d", len(train.Wagons)), "1", 0, "C", false, 0, "")
-	pdf.CellFormat(w[2], 12, fmt.Sprintf("%.2f", train.TotalWeight), "1", 0, "C", false, 0, "")
-	pdf.CellFormat(w[3], 12, fmt.Sprintf("%.2f", train.TotalLength), "1", 0, "C", false, 0, "")
+	pdf.CellFormat(w[2], 12, fmt.Sprintf("%.2f", train.TotalWeight.Tonnes()), "1", 0, "C", false, 0, "")
+	pdf.CellFormat(w[3], 12, fmt.Sprintf("%.2f", train.TotalLength.Metres()), "1", 0, "C", false, 0, "")
 
 	// Highlight Speed (Bold)
 	pdf.SetFont("Arial", "B", 12)
-	pdf.CellFormat(w[4], 12, fmt.Sprintf("%d", res.MaxSpeed), "1", 0, "C", false, 0, "")
+	pdf.CellFormat(w[4], 12, maxSpeedText(res.MaxSpeed), "1", 0, "C", false, 0, "")
 	pdf.Ln(20)
 
 	// --- 4. Brake Specifics ---
@@ -119,9 +198,9 @@ func (g *PDFGenerator) GenerateBrakeLicense(train *domain.Train, res *domain.Cal
 	pdf.CellFormat(0, 10, "BRAKE PERFORMANCE DETAILS:", "0", 1, "L", false, 0, "")
 
 	pdf.SetFont("Arial", "", 10)
-	pdf.Cell(50, 8, fmt.Sprintf("Total Brake Weight:  %.2f tons", train.TotalBrake))
+	pdf.Cell(50, 8, fmt.Sprintf("Total Brake Weight:  %.2f tons", train.TotalBrake.Tonnes()))
 	pdf.Ln(6)
-	pdf.Cell(50, 8, fmt.Sprintf("Brake Percentage:    %d %%", res.BrakePercentage))
+	pdf.Cell(50, 8, brakePercentageLine(res.BrakePercentage))
 	pdf.Ln(6)
 
 	status := "REJECTED"
@@ -133,9 +212,17 @@ func (g *PDFGenerator) GenerateBrakeLicense(train *domain.Train, res *domain.Cal
 	}
 	pdf.Cell(50, 8, fmt.Sprintf("Final Status:        %s", status))
 	pdf.SetTextColor(0, 0, 0) // Reset color
-	pdf.Ln(20)
+	pdf.Ln(12)
+
+	drawBrakingCurve(pdf, res.Braking)
+	drawRouteSections(pdf, res)
+	drawDangerousGoods(pdf, train.Wagons)
 
 	// --- 5. Signatures ---
+	// Keep signatures and the QR code together on one page
+	if _, pageHeight := pdf.GetPageSize(); pdf.GetY() > pageHeight-75 {
+		pdf.AddPage()
+	}
 	pdf.SetFont("Arial", "I", 8)
 	pdf.CellFormat(0, 5, "I certify that the brake test has been performed correctly and the train is safe.", "0", 1, "C", false, 0, "")
 	pdf.Ln(10)
@@ -149,8 +236,177 @@ func (g *PDFGenerator) GenerateBrakeLicense(train *domain.Train, res *domain.Cal
 	pdf.SetFont("Arial", "B", 10)
 	pdf.CellFormat(90, 5, "Train Examiner / Technical Officer", "0", 0, "C", false, 0, "")
 	pdf.CellFormat(90, 5, "Train Boss / Station Master", "0", 1, "C", false, 0, "")
+	pdf.Ln(10)
+
+	// --- 6. Machine-Readable Data & Digital Signature ---
+	attachments := []gofpdf.Attachment{
+		{Content: payload, Filename: "license.json", Description: "Train composition and calculation result"},
+	}
+
+	y = pdf.GetY()
+	pdf.RegisterImageOptionsReader("license-qr", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qrPNG))
+	pdf.ImageOptions("license-qr", 165, y, 30, 30, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+	pdf.SetFont("Courier", "", 8)
+	if envelope != nil {
+		var env SignatureEnvelope
+		if err := json.Unmarshal(envelope, &env); err != nil {
+			return fmt.Errorf("signature envelope is unreadable: %w", err)
+		}
+		attachments = append(attachments, gofpdf.Attachment{Content: envelope, Filename: "signature.json", Description: "RailGuard license signature"})
+		pdf.CellFormat(150, 4, fmt.Sprintf("Digitally signed (%s) by key %s", env.Algorithm, env.KeyID), "0", 1, "L", false, 0, "")
+	} else {
+		pdf.CellFormat(150, 4, "UNSIGNED - not valid without a handwritten signature", "0", 1, "L", false, 0, "")
+	}
+	pdf.CellFormat(150, 4, hashLine(hash), "0", 1, "L", false, 0, "")
+	pdf.CellFormat(150, 4, "Composition data is attached as license.json", "0", 1, "L", false, 0, "")
+	pdf.SetAttachments(attachments)
+
+	return pdf.Output(out)
+}
+
+// orDefault returns s, or def when s is empty
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// Texts only a reprint carries; they are not part of the signed data
+const (
+	duplicateMark = "DUPLICATE"
+	reprintPrefix = "DUPLICATE - reprinted "
+)
+
+// drawDuplicateMark prints a large diagonal watermark across the page
+func drawDuplicateMark(pdf *gofpdf.Fpdf) {
+	pdf.SetFont("Arial", "B", 80)
+	pdf.SetTextColor(230, 230, 230)
+	pdf.TransformBegin()
+	pdf.TransformRotate(45, 105, 150)
+	pdf.Text(35, 180, duplicateMark)
+	pdf.TransformEnd()
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// drawBrakingCurve prints the stopping distances of the train, one column per speed.
+// Licenses issued before the curve was computed have none and print nothing.
+func drawBrakingCurve(pdf *gofpdf.Fpdf, curve *domain.BrakingCurve) {
+	if curve == nil {
+		return
+	}
+	pdf.SetFont("Arial", "B", 11)
+	pdf.CellFormat(0, 8, "BRAKING CURVE:", "0", 1, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Regime %s | Gradient %.0f permil | Deceleration %.2f m/s2 | Build-up %.1f s",
+		curve.Regime, curve.Gradient.Permil(), curve.Deceleration, curve.BuildUpTime), "0", 1, "L", false, 0, "")
 
-	// Output to file
-	filename := fmt.Sprintf("BrakeLicense_%s.pdf", info.TrainNumber)
-	return pdf.OutputFileAndClose(filename)
+	if !curve.CanStop {
+		pdf.SetTextColor(255, 0, 0)
+		pdf.CellFormat(0, 6, "The brakes cannot hold the train on this gradient.", "0", 1, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.Ln(4)
+		return
+	}
+
+	points := curve.Points[1:] // Skip the standstill point
+	label, col := 30.0, 160.0/float64(len(points))
+	pdf.SetFont("Arial", "B", 8)
+	pdf.SetFillColor(220, 220, 220)
+	pdf.CellFormat(label, 6, "Speed (km/h)", "1", 0, "L", true, 0, "")
+	for _, p := range points {
+		pdf.CellFormat(col, 6, fmt.Sprintf("%.0f", p.Speed.KmPerHour()), "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.CellFormat(label, 6, "Distance (m)", "1", 0, "L", true, 0, "")
+	pdf.SetFont("Arial", "", 8)
+	for _, p := range points {
+		pdf.CellFormat(col, 6, fmt.Sprintf("%.0f", p.Distance.Metres()), "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.Ln(4)
+}
+
+// drawRouteSections lists the section speeds of a result computed over a gradient profile.
+func drawRouteSections(pdf *gofpdf.Fpdf, res *domain.CalculationResult) {
+	if len(res.Sections) == 0 {
+		return
+	}
+	pdf.SetFont("Arial", "B", 11)
+	pdf.CellFormat(0, 8, "ROUTE SECTIONS (profile "+res.Profile+"):", "0", 1, "L", false, 0, "")
+
+	w := []float64{25, 25, 30, 35, 35, 40} // Column widths
+	pdf.SetFont("Arial", "B", 8)
+	pdf.SetFillColor(220, 220, 220)
+	for i, header := range []string{"From (km)", "To (km)", "Gradient", "Governing", "Max Speed (km/h)", "Stopping Dist. (m)"} {
+		pdf.CellFormat(w[i], 6, header, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 8)
+	for _, sec := range res.Sections {
+		pdf.CellFormat(w[0], 6, fmt.Sprintf("%.3f", sec.From.Kilometres()), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(w[1], 6, fmt.Sprintf("%.3f", sec.To.Kilometres()), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(w[2], 6, fmt.Sprintf("%+.1f permil", sec.Gradient.Permil()), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(w[3], 6, fmt.Sprintf("%+.1f permil", sec.Governing.Permil()), "1", 0, "C", false, 0, "")
+		if sec.MaxSpeed == 0 {
+			pdf.SetTextColor(255, 0, 0)
+		}
+		pdf.CellFormat(w[4], 6, maxSpeedText(sec.MaxSpeed), "1", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.CellFormat(w[5], 6, fmt.Sprintf("%.0f", sec.StoppingDistance.Metres()), "1", 1, "R", false, 0, "")
+	}
+	pdf.Ln(4)
+}
+
+// drawDangerousGoods lists every dangerous goods entry of the train with the data
+// of its UN number. Nothing is printed for a train without dangerous goods.
+func drawDangerousGoods(pdf *gofpdf.Fpdf, wagons []domain.SelectedWagon) {
+	type row struct {
+		pos   int
+		wagon int
+		cargo domain.DangerousCargo
+	}
+	var rows []row
+	for i, w := range wagons {
+		for _, c := range w.DangerousCargo() {
+			rows = append(rows, row{i + 1, w.WagonSpec.Number, c})
+		}
+	}
+	if len(rows) == 0 {
+		pdf.Ln(8)
+		return
+	}
+
+	pdf.SetFont("Arial", "B", 11)
+	pdf.CellFormat(0, 10, "DANGEROUS GOODS:", "0", 1, "L", false, 0, "")
+
+	w := []float64{12, 28, 18, 70, 16, 20, 26} // Column widths
+	pdf.SetFont("Arial", "B", 9)
+	pdf.SetFillColor(220, 220, 220)
+	for i, header := range []string{"Pos", "Wagon No", "UN No", "Proper Shipping Name", "Class", "Hazard No", "Quantity"} {
+		pdf.CellFormat(w[i], 7, header, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 9)
+	for _, r := range rows {
+		un, name, class, hazard := "-", "-", r.cargo.Code, "-"
+		if g := r.cargo.Goods; g != nil {
+			un, name, class, hazard = "UN"+g.UNNumber, g.ProperName, g.Class, orDefault(g.HazardNumber, "-")
+		}
+		quantity := "-"
+		if r.cargo.Quantity > 0 {
+			quantity = fmt.Sprintf("%.0f", r.cargo.Quantity)
+		}
+		pdf.CellFormat(w[0], 7, fmt.Sprintf("%d", r.pos), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(w[1], 7, fmt.Sprintf("%d", r.wagon), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(w[2], 7, un, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(w[3], 7, name, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(w[4], 7, class, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(w[5], 7, hazard, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(w[6], 7, quantity, "1", 1, "R", false, 0, "")
+	}
+	pdf.Ln(8)
 }
@@ -0,0 +1,74 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// pdfStreams returns the decoded content of every stream object in a PDF
+// produced by gofpdf. Only FlateDecode and unfiltered streams are supported,
+// which covers everything GenerateBrakeLicense writes.
+func pdfStreams(data []byte) [][]byte {
+	var streams [][]byte
+	marker := []byte("stream\n")
+	pos := 0
+	for {
+		i := bytes.Index(data[pos:], marker)
+		if i < 0 {
+			break
+		}
+		start := pos + i
+		pos = start + len(marker)
+
+		// "endstream\n" also contains the marker, skip it
+		if start >= 3 && string(data[start-3:start]) == "end" {
+			continue
+		}
+		end := bytes.Index(data[pos:], []byte("\nendstream"))
+		if end < 0 {
+			break
+		}
+		raw := data[pos : pos+end]
+
+		// The stream dictionary sits between the last "obj" and the marker
+		dictStart := bytes.LastIndex(data[:start], []byte("obj"))
+		if dictStart < 0 {
+			dictStart = 0
+		}
+		dict := data[dictStart:start]
+
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			zr, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				continue
+			}
+			decoded, err := io.ReadAll(zr)
+			zr.Close()
+			if err != nil {
+				continue
+			}
+			raw = decoded
+		}
+		streams = append(streams, raw)
+		pos += end
+	}
+	return streams
+}
+
+var textOperand = regexp.MustCompile(`\(((?:\\.|[^\\)])*)\)\s*Tj`)
+
+// pdfTexts collects the strings drawn with the Tj operator across all streams.
+func pdfTexts(streams [][]byte) []string {
+	unescape := strings.NewReplacer(`\(`, "(", `\)`, ")", `\\`, `\`, `\r`, "\r")
+
+	var texts []string
+	for _, s := range streams {
+		for _, m := range textOperand.FindAllSubmatch(s, -1) {
+			texts = append(texts, unescape.Replace(string(m[1])))
+		}
+	}
+	return texts
+}
@@ -0,0 +1,77 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"railguard/internal/core/domain"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// GenerateCalculationTrace prints the step-by-step account of a calculation result.
+// The trace is a working document for examiners and is not signed.
+func (g *PDFGenerator) GenerateCalculationTrace(out io.Writer, res *domain.CalculationResult) error {
+	trace := res.Trace
+	if trace == nil {
+		return errors.New("the result has no calculation trace")
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(190, 10, "BRAKE CALCULATION TRACE", "0", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	pdf.CellFormat(190, 6, fmt.Sprintf("Printed %s | Rules %s | Slope %g permil",
+		time.Now().Format("2006-01-02 15:04"), res.RuleVersion, trace.Slope.Permil()), "0", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	// --- 1. Vehicles ---
+	w := []float64{10, 38, 18, 20, 16, 12, 76} // Column widths
+	header := func() {
+		pdf.SetFont("Arial", "B", 8)
+		pdf.SetFillColor(220, 220, 220)
+		for i, h := range []string{"Pos", "Vehicle", "Weight (t)", "Brake (t)", "Length", "Axles", "Reason"} {
+			pdf.CellFormat(w[i], 6, h, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Arial", "", 8)
+	}
+	header()
+	_, pageHeight := pdf.GetPageSize()
+	for _, v := range trace.Vehicles {
+		if pdf.GetY() > pageHeight-25 {
+			pdf.AddPage()
+			header()
+		}
+		pdf.CellFormat(w[0], 6, fmt.Sprintf("%d", v.Position), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(w[1], 6, v.Label, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(w[2], 6, fmt.Sprintf("%.2f", v.Weight.Tonnes()), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(w[3], 6, fmt.Sprintf("%.2f", v.BrakeWeight.Tonnes()), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(w[4], 6, fmt.Sprintf("%.2f", v.Length.Metres()), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(w[5], 6, fmt.Sprintf("%d", v.Axles), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(w[6], 6, v.Reason, "1", 1, "L", false, 0, "")
+	}
+
+	// Totals
+	pdf.SetFont("Arial", "B", 8)
+	pdf.CellFormat(w[0]+w[1], 6, "Totals", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(w[2], 6, fmt.Sprintf("%.2f", trace.TotalWeight.Tonnes()), "1", 0, "R", true, 0, "")
+	pdf.CellFormat(w[3], 6, fmt.Sprintf("%.2f", trace.TotalBrake.Tonnes()), "1", 0, "R", true, 0, "")
+	pdf.CellFormat(w[4], 6, fmt.Sprintf("%.2f", trace.TotalLength.Metres()), "1", 0, "R", true, 0, "")
+	pdf.CellFormat(w[5], 6, fmt.Sprintf("%d", trace.AxleCount), "1", 0, "C", true, 0, "")
+	pdf.CellFormat(w[6], 6, "", "1", 1, "L", true, 0, "")
+	pdf.Ln(6)
+
+	// --- 2. Steps ---
+	pdf.SetFont("Arial", "B", 11)
+	pdf.CellFormat(0, 8, "STEPS:", "0", 1, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	for i, step := range trace.Steps {
+		pdf.MultiCell(0, 5, fmt.Sprintf("%d. %s", i+1, step), "", "L", false)
+	}
+
+	return pdf.Output(out)
+}
@@ -0,0 +1,149 @@
+package signature
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// Algorithm is the only signature scheme used for brake licenses.
+	Algorithm = "ed25519"
+
+	privateKeyFile = "examiner_ed25519.key"
+	publicKeyFile  = "examiner_ed25519.pub"
+)
+
+// Signer holds the examiner's device key pair used to sign brake licenses.
+type Signer struct {
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// LoadOrCreateSigner reads the key pair from dir, generating a new one on first use.
+func LoadOrCreateSigner(dir string) (*Signer, error) {
+	privPath := filepath.Join(dir, privateKeyFile)
+	if _, err := os.Stat(privPath); errors.Is(err, os.ErrNotExist) {
+		return GenerateKeyPair(dir)
+	}
+	return LoadSigner(privPath)
+}
+
+// GenerateKeyPair creates a new key pair and writes it to dir as PEM files.
+// The private key file is only readable by the current user.
+func GenerateKeyPair(dir string) (*Signer, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	privBytes, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	pubBytes, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privBytes})
+	if err := os.WriteFile(filepath.Join(dir, privateKeyFile), privPEM, 0o600); err != nil {
+		return nil, err
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes})
+	if err := os.WriteFile(filepath.Join(dir, publicKeyFile), pubPEM, 0o644); err != nil {
+		return nil, err
+	}
+
+	return &Signer{privateKey: priv, publicKey: pub}, nil
+}
+
+// LoadSigner reads a PEM encoded PKCS#8 ed25519 private key.
+func LoadSigner(path string) (*Signer, error) {
+	block, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an %s private key", path, Algorithm)
+	}
+	return &Signer{privateKey: priv, publicKey: priv.Public().(ed25519.PublicKey)}, nil
+}
+
+// LoadPublicKey reads a PEM encoded ed25519 public key, as written by GenerateKeyPair.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an %s public key", path, Algorithm)
+	}
+	return pub, nil
+}
+
+// PublicKeyPath returns where GenerateKeyPair stores the public key inside dir.
+func PublicKeyPath(dir string) string {
+	return filepath.Join(dir, publicKeyFile)
+}
+
+// Sign returns the signature of data.
+func (s *Signer) Sign(data []byte) []byte {
+	return ed25519.Sign(s.privateKey, data)
+}
+
+// PublicKey returns the public half of the key pair.
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.publicKey
+}
+
+// KeyID returns a short fingerprint of the public key, printed on the license.
+func (s *Signer) KeyID() string {
+	return KeyID(s.publicKey)
+}
+
+// KeyID returns the first 8 bytes of the SHA-256 of pub, hex encoded.
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Verify reports whether sig is a valid signature of data by pub.
+func Verify(pub ed25519.PublicKey, data, sig []byte) bool {
+	if len(pub) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(pub, data, sig)
+}
+
+func readPEM(path, blockType string) (*pem.Block, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s does not contain a %s block", path, blockType)
+	}
+	return block, nil
+}
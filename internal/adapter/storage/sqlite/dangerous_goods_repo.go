@@ -0,0 +1,152 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"railguard/internal/core/domain"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// goodsSearchLimit caps the rows returned by one search
+const goodsSearchLimit = 50
+
+type DangerousGoodsRepository struct {
+	db *sql.DB
+}
+
+func NewDangerousGoodsRepository(dbPath string) (*DangerousGoodsRepository, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &DangerousGoodsRepository{db: db}
+	if err := repo.initTable(); err != nil {
+		return nil, err
+	}
+	if err := repo.seedGoods(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func (r *DangerousGoodsRepository) initTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS dangerous_goods_list (
+		un_number TEXT PRIMARY KEY,
+		proper_name TEXT,
+		class TEXT,
+		classification_code TEXT,
+		packing_group TEXT DEFAULT '',
+		hazard_number TEXT DEFAULT '',
+		special_provisions TEXT DEFAULT ''
+	);`
+	_, err := r.db.Exec(query)
+	return err
+}
+
+const goodsColumns = `un_number, proper_name, class, classification_code, packing_group, hazard_number, special_provisions`
+
+// normalizeUN strips the "UN" prefix and spaces operators copy from shipping documents
+func normalizeUN(un string) string {
+	un = strings.ToUpper(strings.TrimSpace(un))
+	return strings.TrimSpace(strings.TrimPrefix(un, "UN"))
+}
+
+func (r *DangerousGoodsRepository) GetByUNNumber(un string) (*domain.DangerousGood, error) {
+	row := r.db.QueryRow(`SELECT `+goodsColumns+` FROM dangerous_goods_list WHERE un_number = ?`, normalizeUN(un))
+	var g domain.DangerousGood
+	err := row.Scan(&g.UNNumber, &g.ProperName, &g.Class, &g.ClassificationCode, &g.PackingGroup, &g.HazardNumber, &g.SpecialProvisions)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("UN%s is not in the dangerous goods list", normalizeUN(un))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (r *DangerousGoodsRepository) SearchGoods(query string) ([]domain.DangerousGood, error) {
+	q := normalizeUN(query)
+	rows, err := r.db.Query(`SELECT `+goodsColumns+` FROM dangerous_goods_list
+		WHERE un_number LIKE ? OR proper_name LIKE ? ORDER BY un_number LIMIT ?`,
+		q+"%", "%"+strings.TrimSpace(query)+"%", goodsSearchLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []domain.DangerousGood
+	for rows.Next() {
+		var g domain.DangerousGood
+		if err := rows.Scan(&g.UNNumber, &g.ProperName, &g.Class, &g.ClassificationCode, &g.PackingGroup, &g.HazardNumber, &g.SpecialProvisions); err != nil {
+			return nil, err
+		}
+		list = append(list, g)
+	}
+	return list, rows.Err()
+}
+
+// seedGoods inserts the substances most often carried by rail, when the list is empty.
+// Further entries can be added to the table from the full RID list.
+func (r *DangerousGoodsRepository) seedGoods() error {
+	var count int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM dangerous_goods_list").Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	fmt.Println("Seeding Dangerous Goods List...")
+
+	goods := []domain.DangerousGood{
+		{UNNumber: "0081", ProperName: "Explosive, blasting, type A", Class: "1", ClassificationCode: "1.1D"},
+		{UNNumber: "1005", ProperName: "Ammonia, anhydrous", Class: "2", ClassificationCode: "2TC", HazardNumber: "268"},
+		{UNNumber: "1011", ProperName: "Butane", Class: "2", ClassificationCode: "2F", HazardNumber: "23"},
+		{UNNumber: "1017", ProperName: "Chlorine", Class: "2", ClassificationCode: "2TOC", HazardNumber: "265"},
+		{UNNumber: "1049", ProperName: "Hydrogen, compressed", Class: "2", ClassificationCode: "1F", HazardNumber: "23"},
+		{UNNumber: "1066", ProperName: "Nitrogen, compressed", Class: "2", ClassificationCode: "1A", HazardNumber: "20"},
+		{UNNumber: "1072", ProperName: "Oxygen, compressed", Class: "2", ClassificationCode: "1O", HazardNumber: "25"},
+		{UNNumber: "1073", ProperName: "Oxygen, refrigerated liquid", Class: "2", ClassificationCode: "3O", HazardNumber: "225"},
+		{UNNumber: "1075", ProperName: "Petroleum gases, liquefied", Class: "2", ClassificationCode: "2F", HazardNumber: "23"},
+		{UNNumber: "1079", ProperName: "Sulphur dioxide", Class: "2", ClassificationCode: "2TC", HazardNumber: "268"},
+		{UNNumber: "1090", ProperName: "Acetone", Class: "3", ClassificationCode: "F1", PackingGroup: "II", HazardNumber: "33"},
+		{UNNumber: "1114", ProperName: "Benzene", Class: "3", ClassificationCode: "F1", PackingGroup: "II", HazardNumber: "33"},
+		{UNNumber: "1170", ProperName: "Ethanol", Class: "3", ClassificationCode: "F1", PackingGroup: "II", HazardNumber: "33"},
+		{UNNumber: "1202", ProperName: "Diesel fuel", Class: "3", ClassificationCode: "F1", PackingGroup: "III", HazardNumber: "30"},
+		{UNNumber: "1203", ProperName: "Petrol", Class: "3", ClassificationCode: "F1", PackingGroup: "II", HazardNumber: "33", SpecialProvisions: "243 534 664"},
+		{UNNumber: "1223", ProperName: "Kerosene", Class: "3", ClassificationCode: "F1", PackingGroup: "III", HazardNumber: "30"},
+		{UNNumber: "1230", ProperName: "Methanol", Class: "3", ClassificationCode: "FT1", PackingGroup: "II", HazardNumber: "336"},
+		{UNNumber: "1350", ProperName: "Sulphur", Class: "4.1", ClassificationCode: "F3", PackingGroup: "III", HazardNumber: "40"},
+		{UNNumber: "1402", ProperName: "Calcium carbide", Class: "4.3", ClassificationCode: "W2", PackingGroup: "I", HazardNumber: "X423"},
+		{UNNumber: "1428", ProperName: "Sodium", Class: "4.3", ClassificationCode: "W2", PackingGroup: "I", HazardNumber: "X423"},
+		{UNNumber: "1547", ProperName: "Aniline", Class: "6.1", ClassificationCode: "T1", PackingGroup: "II", HazardNumber: "60"},
+		{UNNumber: "1593", ProperName: "Dichloromethane", Class: "6.1", ClassificationCode: "T1", PackingGroup: "III", HazardNumber: "60"},
+		{UNNumber: "1824", ProperName: "Sodium hydroxide solution", Class: "8", ClassificationCode: "C5", PackingGroup: "II", HazardNumber: "80"},
+		{UNNumber: "1830", ProperName: "Sulphuric acid", Class: "8", ClassificationCode: "C1", PackingGroup: "II", HazardNumber: "80"},
+		{UNNumber: "1942", ProperName: "Ammonium nitrate", Class: "5.1", ClassificationCode: "O2", PackingGroup: "III", HazardNumber: "50"},
+		{UNNumber: "1977", ProperName: "Nitrogen, refrigerated liquid", Class: "2", ClassificationCode: "3A", HazardNumber: "22"},
+		{UNNumber: "2014", ProperName: "Hydrogen peroxide, aqueous solution", Class: "5.1", ClassificationCode: "OC1", PackingGroup: "II", HazardNumber: "58"},
+		{UNNumber: "2448", ProperName: "Sulphur, molten", Class: "4.1", ClassificationCode: "F3", PackingGroup: "III", HazardNumber: "44"},
+		{UNNumber: "2912", ProperName: "Radioactive material, low specific activity (LSA-I)", Class: "7", HazardNumber: "70"},
+		{UNNumber: "3077", ProperName: "Environmentally hazardous substance, solid, n.o.s.", Class: "9", ClassificationCode: "M7", PackingGroup: "III", HazardNumber: "90"},
+		{UNNumber: "3082", ProperName: "Environmentally hazardous substance, liquid, n.o.s.", Class: "9", ClassificationCode: "M6", PackingGroup: "III", HazardNumber: "90"},
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, g := range goods {
+		_, err := tx.Exec(`INSERT OR IGNORE INTO dangerous_goods_list (`+goodsColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			g.UNNumber, g.ProperName, g.Class, g.ClassificationCode, g.PackingGroup, g.HazardNumber, g.SpecialProvisions)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
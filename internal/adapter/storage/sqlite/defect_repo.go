@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"railguard/internal/core/domain"
+	"strconv"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// defectListLimit caps the rows returned when listing the register
+const defectListLimit = 1000
+
+type DefectRepository struct {
+	db *sql.DB
+}
+
+func NewDefectRepository(dbPath string) (*DefectRepository, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &DefectRepository{db: db}
+	if err := repo.initTable(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func (r *DefectRepository) initTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS wagon_defects (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		wagon_number INTEGER NOT NULL,
+		component TEXT NOT NULL,
+		train_number TEXT DEFAULT '',
+		report_count INTEGER DEFAULT 1,
+		opened_at DATETIME,
+		last_seen_at DATETIME,
+		closed_at DATETIME,
+		close_note TEXT DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_defects_wagon ON wagon_defects(wagon_number, closed_at);`
+	_, err := r.db.Exec(query)
+	return err
+}
+
+// ReportDefects keeps at most one open defect per wagon and component
+func (r *DefectRepository) ReportDefects(wagonNumber int, components []domain.DefectComponent, trainNumber string) error {
+	if len(components) == 0 {
+		return nil
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, c := range components {
+		res, err := tx.Exec(`UPDATE wagon_defects
+			SET report_count = report_count + 1, last_seen_at = ?,
+				train_number = CASE WHEN ? = '' THEN train_number ELSE ? END
+			WHERE wagon_number = ? AND component = ? AND closed_at IS NULL`,
+			now, trainNumber, trainNumber, wagonNumber, string(c))
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			continue
+		}
+		_, err = tx.Exec(`INSERT INTO wagon_defects (wagon_number, component, train_number, opened_at, last_seen_at)
+			VALUES (?, ?, ?, ?, ?)`, wagonNumber, string(c), trainNumber, now, now)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AttachTrain fills in the train of open defects reported without one
+func (r *DefectRepository) AttachTrain(wagonNumbers []int, trainNumber string) error {
+	if trainNumber == "" || len(wagonNumbers) == 0 {
+		return nil
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, number := range wagonNumbers {
+		_, err := tx.Exec(`UPDATE wagon_defects SET train_number = ?
+			WHERE wagon_number = ? AND closed_at IS NULL AND train_number = ''`, trainNumber, number)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *DefectRepository) OpenDefects(wagonNumber int) ([]domain.Defect, error) {
+	return r.query(`WHERE wagon_number = ? AND closed_at IS NULL ORDER BY opened_at`, wagonNumber)
+}
+
+func (r *DefectRepository) ListDefects(openOnly bool) ([]domain.Defect, error) {
+	where := ""
+	if openOnly {
+		where = "WHERE closed_at IS NULL "
+	}
+	return r.query(where + "ORDER BY last_seen_at DESC LIMIT " + strconv.Itoa(defectListLimit))
+}
+
+// CloseDefect marks a defect as repaired by the workshop
+func (r *DefectRepository) CloseDefect(id int, note string) error {
+	res, err := r.db.Exec(`UPDATE wagon_defects SET closed_at = ?, close_note = ? WHERE id = ? AND closed_at IS NULL`,
+		time.Now(), note, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("defect not found or already closed")
+	}
+	return nil
+}
+
+func (r *DefectRepository) query(clause string, args ...interface{}) ([]domain.Defect, error) {
+	rows, err := r.db.Query(`SELECT id, wagon_number, component, train_number, report_count,
+		opened_at, last_seen_at, closed_at, close_note FROM wagon_defects `+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []domain.Defect
+	for rows.Next() {
+		var d domain.Defect
+		var component string
+		var closedAt sql.NullTime
+		err := rows.Scan(&d.ID, &d.WagonNumber, &component, &d.TrainNumber, &d.ReportCount,
+			&d.OpenedAt, &d.LastSeenAt, &closedAt, &d.CloseNote)
+		if err != nil {
+			return nil, err
+		}
+		d.Component = domain.DefectComponent(component)
+		if closedAt.Valid {
+			d.ClosedAt = closedAt.Time
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
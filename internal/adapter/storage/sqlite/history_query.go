@@ -0,0 +1,151 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/uic"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// History sort orders accepted by HistoryQuery.SortBy
+const (
+	SortByCreated     = "created_at"
+	SortByTrainNumber = "train_number"
+	SortByWeight      = "total_weight"
+	SortByMaxSpeed    = "max_speed"
+)
+
+var historySortColumns = map[string]bool{
+	SortByCreated: true, SortByTrainNumber: true, SortByWeight: true, SortByMaxSpeed: true,
+}
+
+// HistoryQuery selects one page of saved trains. Zero-valued filters match everything.
+type HistoryQuery struct {
+	Offset int
+	Limit  int // 0 = 50
+
+	SortBy string // One of the SortBy* constants, default SortByCreated
+	Asc    bool   // Default is descending (newest / largest first)
+
+	From              time.Time
+	To                time.Time // Inclusive, whole day
+	TrainNumberPrefix string
+	DriverName        string
+	Safe              *bool // nil = any verdict
+	WagonNumber       int   // Only trains containing this wagon, by catalogue key (see uic.CatalogueKey)
+}
+
+// HistoryPage is one page of query results.
+// Items carry trip info and results only; Locos and Wagons are not loaded,
+// use GetHistory to fetch the composition of a single entry.
+type HistoryPage struct {
+	Items []HistoryItem
+	Total int // Number of entries matching the filters
+}
+
+// QueryHistory returns a filtered, sorted page of the history without decoding compositions
+func (r *WagonRepository) QueryHistory(q HistoryQuery) (*HistoryPage, error) {
+	where, args := q.where()
+
+	page := &HistoryPage{}
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM train_history"+where, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	sortBy := SortByCreated
+	if historySortColumns[q.SortBy] {
+		sortBy = q.SortBy
+	}
+	dir := "DESC"
+	if q.Asc {
+		dir = "ASC"
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `SELECT id, train_number, driver_name, created_at, slope, total_weight, max_speed,
+		origin, destination, train_boss, trip_date, trip_time, result_json, validation_json, COALESCE(wagon_count, 0)
+		FROM train_history` + where + " ORDER BY " + sortBy + " " + dir + ", id " + dir + " LIMIT ? OFFSET ?"
+
+	rows, err := r.db.Query(query, append(args, limit, q.Offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var h HistoryItem
+		var resultJson, validationJson string
+		t := &h.Trip
+
+		err := rows.Scan(&h.ID, &t.TrainNumber, &t.DriverName, &h.CreatedAt, &h.Slope, &h.TotalWeight, &h.MaxSpeed,
+			&t.Origin, &t.Destination, &t.TrainBossName, &t.Date, &t.Time, &resultJson, &validationJson, &h.WagonCount)
+		if err != nil {
+			return nil, err
+		}
+		if resultJson != "" {
+			h.Result = &domain.CalculationResult{}
+			json.Unmarshal([]byte(resultJson), h.Result)
+		}
+		if validationJson != "" {
+			h.Validation = &domain.ValidationResult{}
+			json.Unmarshal([]byte(validationJson), h.Validation)
+		}
+		page.Items = append(page.Items, h)
+	}
+	return page, rows.Err()
+}
+
+func (q HistoryQuery) where() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+
+	if !q.From.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, startOfDay(q.From))
+	}
+	if !q.To.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, startOfDay(q.To).AddDate(0, 0, 1))
+	}
+	if q.TrainNumberPrefix != "" {
+		conds = append(conds, "train_number LIKE ? ESCAPE '\\'")
+		args = append(args, escapeLike(q.TrainNumberPrefix)+"%")
+	}
+	if q.DriverName != "" {
+		conds = append(conds, "driver_name LIKE ? ESCAPE '\\'")
+		args = append(args, "%"+escapeLike(q.DriverName)+"%")
+	}
+	if q.Safe != nil {
+		conds = append(conds, "is_safe = ?")
+		args = append(args, *q.Safe)
+	}
+	if q.WagonNumber != 0 {
+		// Compositions record UIC numbers with their check digit, so the key matches all ten
+		lo, hi := q.WagonNumber, q.WagonNumber
+		if len(strconv.Itoa(q.WagonNumber)) > uic.NationalDigits {
+			lo, hi = q.WagonNumber*10, q.WagonNumber*10+9
+		}
+		conds = append(conds, `EXISTS (SELECT 1 FROM train_history_wagons w
+			WHERE w.history_id = train_history.id AND w.wagon_number BETWEEN ? AND ?)`)
+		args = append(args, lo, hi)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
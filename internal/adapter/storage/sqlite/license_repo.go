@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"database/sql"
+	"railguard/internal/core/domain"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const issueDayFormat = "2006-01-02"
+
+// searchLimit caps the rows returned by a single archive search
+const searchLimit = 500
+
+type LicenseRepository struct {
+	db *sql.DB
+}
+
+func NewLicenseRepository(dbPath string) (*LicenseRepository, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := &LicenseRepository{db: db}
+	if err := repo.initTable(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func (r *LicenseRepository) initTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS licenses (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		train_number TEXT,
+		issue_day TEXT,
+		sequence INTEGER,
+		file_name TEXT,
+		location TEXT,
+		file_hash TEXT,
+		issued_at DATETIME
+	);`
+	if _, err := r.db.Exec(query); err != nil {
+		return err
+	}
+
+	// Archive columns, added after the first release of the table
+	err := ensureColumns(r.db, "licenses", []column{
+		{"origin", "TEXT DEFAULT ''"},
+		{"destination", "TEXT DEFAULT ''"},
+		{"driver_name", "TEXT DEFAULT ''"},
+		{"train_boss", "TEXT DEFAULT ''"},
+		{"trip_date", "TEXT DEFAULT ''"},
+		{"trip_time", "TEXT DEFAULT ''"},
+		{"brake_percentage", "INTEGER DEFAULT 0"},
+		{"max_speed", "INTEGER DEFAULT 0"},
+		{"is_safe", "INTEGER DEFAULT 0"},
+		{"message", "TEXT DEFAULT ''"},
+		{"rule_version", "TEXT DEFAULT ''"},
+		{"wagon_numbers", "TEXT DEFAULT ''"},
+		{"payload_json", "BLOB"},
+		{"signature_json", "BLOB"},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+	CREATE INDEX IF NOT EXISTS idx_licenses_train ON licenses(train_number, issue_day);
+	CREATE INDEX IF NOT EXISTS idx_licenses_issued ON licenses(issued_at);`)
+	return err
+}
+
+// NextSequence numbers licenses per train and day, starting at 1
+func (r *LicenseRepository) NextSequence(trainNumber string, day time.Time) (int, error) {
+	var seq int
+	err := r.db.QueryRow(`SELECT COALESCE(MAX(sequence), 0) + 1 FROM licenses WHERE train_number = ? AND issue_day = ?`,
+		trainNumber, day.Format(issueDayFormat)).Scan(&seq)
+	return seq, err
+}
+
+// SaveLicense archives an issued license and returns its ID
+func (r *LicenseRepository) SaveLicense(rec domain.LicenseRecord) (int, error) {
+	query := `INSERT INTO licenses (
+		train_number, issue_day, sequence, file_name, location, file_hash, issued_at,
+		origin, destination, driver_name, train_boss, trip_date, trip_time,
+		brake_percentage, max_speed, is_safe, message, rule_version, wagon_numbers, payload_json, signature_json
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	t := rec.Trip
+	res, err := r.db.Exec(query,
+		t.TrainNumber, rec.IssuedAt.Format(issueDayFormat), rec.Sequence, rec.FileName, rec.Location, rec.FileHash, rec.IssuedAt,
+		t.Origin, t.Destination, t.DriverName, t.TrainBossName, t.Date, t.Time,
+		rec.Result.BrakePercentage, rec.Result.MaxSpeed.KmPerHour(), rec.Result.IsSafe, rec.Result.Message, rec.Result.RuleVersion,
+		encodeWagonNumbers(rec.WagonNumbers), rec.Payload, rec.Signature,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// SearchLicenses filters the archive; every non-zero filter field must match
+func (r *LicenseRepository) SearchLicenses(f domain.LicenseFilter) ([]domain.LicenseRecord, error) {
+	query := `SELECT id, train_number, sequence, file_name, location, file_hash, issued_at,
+		origin, destination, driver_name, train_boss, trip_date, trip_time,
+		brake_percentage, max_speed, is_safe, message, rule_version, wagon_numbers, payload_json, signature_json
+		FROM licenses WHERE 1 = 1`
+	var args []interface{}
+
+	if f.TrainNumber != "" {
+		query += " AND train_number = ?"
+		args = append(args, f.TrainNumber)
+	}
+	if f.DriverName != "" {
+		query += " AND driver_name LIKE ?"
+		args = append(args, "%"+f.DriverName+"%")
+	}
+	if !f.From.IsZero() {
+		query += " AND issue_day >= ?"
+		args = append(args, f.From.Format(issueDayFormat))
+	}
+	if !f.To.IsZero() {
+		query += " AND issue_day <= ?"
+		args = append(args, f.To.Format(issueDayFormat))
+	}
+	if f.WagonNumber != 0 {
+		query += " AND instr(wagon_numbers, ?) > 0"
+		args = append(args, ","+strconv.Itoa(f.WagonNumber)+",")
+	}
+	query += " ORDER BY issued_at DESC LIMIT " + strconv.Itoa(searchLimit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []domain.LicenseRecord
+	for rows.Next() {
+		var rec domain.LicenseRecord
+		var wagonNumbers string
+		t := &rec.Trip
+		err := rows.Scan(&rec.ID, &t.TrainNumber, &rec.Sequence, &rec.FileName, &rec.Location, &rec.FileHash, &rec.IssuedAt,
+			&t.Origin, &t.Destination, &t.DriverName, &t.TrainBossName, &t.Date, &t.Time,
+			&rec.Result.BrakePercentage, &rec.Result.MaxSpeed, &rec.Result.IsSafe, &rec.Result.Message, &rec.Result.RuleVersion,
+			&wagonNumbers, &rec.Payload, &rec.Signature)
+		if err != nil {
+			return nil, err
+		}
+		rec.WagonNumbers = decodeWagonNumbers(wagonNumbers)
+		list = append(list, rec)
+	}
+	return list, rows.Err()
+}
+
+// encodeWagonNumbers stores numbers as ",n1,n2," so a single wagon can be found with instr()
+func encodeWagonNumbers(numbers []int) string {
+	if len(numbers) == 0 {
+		return ""
+	}
+	parts := make([]string, len(numbers))
+	for i, n := range numbers {
+		parts[i] = strconv.Itoa(n)
+	}
+	return "," + strings.Join(parts, ",") + ","
+}
+
+func decodeWagonNumbers(s string) []int {
+	var numbers []int
+	for _, p := range strings.Split(strings.Trim(s, ","), ",") {
+		if n, err := strconv.Atoi(p); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
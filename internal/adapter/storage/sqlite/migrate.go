@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// column is a column name with its SQL type definition
+type column struct {
+	name string
+	def  string
+}
+
+// ensureColumns adds columns that an older database file is missing.
+// CREATE TABLE IF NOT EXISTS never changes an existing table, so every column
+// added after a table's first release must also be listed here.
+func ensureColumns(db *sql.DB, table string, columns []column) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, c := range columns {
+		if existing[c.name] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, c.name, c.def)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
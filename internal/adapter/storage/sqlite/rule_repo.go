@@ -1,9 +1,13 @@
 package sqlite
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
 
 	_ "github.com/mattn/go-sqlite3" // Ensure driver is imported
 )
@@ -35,15 +39,83 @@ func (r *SQLiteRuleRepo) initTable() {
 	r.db.Exec(query)
 }
 
+// speedRule allows speed on slopes up to maxSlope from minPercent brake percentage
+type speedRule struct {
+	maxSlope   units.Gradient
+	minPercent int
+	speed      units.Speed
+}
+
+// speedTable is read top to bottom, the first slope band that fits applies
+var speedTable = []speedRule{
+	{maxSlope: 20 * units.PerMil, minPercent: 40, speed: 60 * units.KilometrePerHour},
+	{maxSlope: units.Gradient(math.Inf(1)), minPercent: 40, speed: 40 * units.KilometrePerHour},
+}
+
+// speedBand returns the rules of the slope band that applies to slope, with their row numbers
+func speedBand(slope units.Gradient) (rules []speedRule, rows []int) {
+	for i, rule := range speedTable {
+		if slope <= rule.maxSlope && (len(rules) == 0 || rule.maxSlope == rules[0].maxSlope) {
+			rules = append(rules, rule)
+			rows = append(rows, i+1)
+		}
+	}
+	return rules, rows
+}
+
+// bandLabel describes the slope band of a row for calculation traces
+func bandLabel(row int) string {
+	rule := speedTable[row-1]
+	if !math.IsInf(float64(rule.maxSlope), 1) {
+		return fmt.Sprintf("slope <= %g permil", rule.maxSlope.Permil())
+	}
+	for i := row - 2; i >= 0; i-- {
+		if speedTable[i].maxSlope != rule.maxSlope {
+			return fmt.Sprintf("slope > %g permil", speedTable[i].maxSlope.Permil())
+		}
+	}
+	return "any slope"
+}
+
 // GetMaxSpeed implementation
-func (r *SQLiteRuleRepo) GetMaxSpeed(slope, brakePercent int) (int, error) {
-	if brakePercent < 40 {
-		return 0, nil
+func (r *SQLiteRuleRepo) GetMaxSpeed(slope units.Gradient, brakePercent int) (units.Speed, error) {
+	rule, err := r.GetSpeedRule(slope, brakePercent)
+	return rule.MaxSpeed, err
+}
+
+// GetSpeedRule picks the fastest row of the slope band the brake percentage reaches
+func (r *SQLiteRuleRepo) GetSpeedRule(slope units.Gradient, brakePercent int) (domain.SpeedRule, error) {
+	rules, rows := speedBand(slope)
+	var cell domain.SpeedRule
+	for i, rule := range rules {
+		if brakePercent >= rule.minPercent && rule.speed > cell.MaxSpeed {
+			cell = domain.SpeedRule{Row: rows[i], Band: bandLabel(rows[i]), MinPercentage: rule.minPercent, MaxSpeed: rule.speed}
+		}
 	}
-	if slope > 20 {
-		return 40, nil
+	if cell.Row == 0 && len(rules) > 0 {
+		// Below every row of the band: report the lowest requirement that was missed
+		cell.Band = bandLabel(rows[0])
+		cell.MinPercentage = rules[0].minPercent
+		for _, rule := range rules[1:] {
+			cell.MinPercentage = min(cell.MinPercentage, rule.minPercent)
+		}
 	}
-	return 60, nil
+	return cell, nil
+}
+
+// GetMinBrakePercentage is the inverse of GetMaxSpeed
+func (r *SQLiteRuleRepo) GetMinBrakePercentage(slope units.Gradient, speed units.Speed) (int, bool, error) {
+	if speed <= 0 {
+		return 0, true, nil
+	}
+	percent, found := 0, false
+	rules, _ := speedBand(slope)
+	for _, rule := range rules {
+		if rule.speed >= speed && (!found || rule.minPercent < percent) {
+			percent, found = rule.minPercent, true
+		}
+	}
+	return percent, found, nil
 }
 
 // GetAllDangerRules fetches the matrix from DB
@@ -65,16 +137,40 @@ func (r *SQLiteRuleRepo) GetAllDangerRules() ([]domain.DangerRule, error) {
 	return rules, nil
 }
 
-// seedRules inserts standard railway compatibility rules
+// speedTableVersion must be bumped whenever GetMaxSpeed changes
+const speedTableVersion = "speed-v1"
+
+// GetRuleVersion combines the speed table version with a digest of the danger matrix
+func (r *SQLiteRuleRepo) GetRuleVersion() (string, error) {
+	rows, err := r.db.Query("SELECT code_a, code_b, status FROM danger_rules ORDER BY code_a, code_b")
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	h := sha256.New()
+	for rows.Next() {
+		var a, b, status string
+		if err := rows.Scan(&a, &b, &status); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s|%s|%s\n", a, b, status)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/danger-%s", speedTableVersion, hex.EncodeToString(h.Sum(nil))[:8]), nil
+}
+
+// seedRules inserts standard railway compatibility rules. Pairs already in the
+// table are kept, so a matrix seeded before a class had rules gets them added.
 func (r *SQLiteRuleRepo) seedRules() {
 	var count int
 	r.db.QueryRow("SELECT COUNT(*) FROM danger_rules").Scan(&count)
-	if count > 0 {
-		return
+	if count == 0 {
+		fmt.Println("Seeding Dangerous Goods Matrix...")
 	}
 
-	fmt.Println("Seeding Dangerous Goods Matrix...")
-
 	// Status legend: (-) Forbidden, (+) Allowed, (1) 1 Wagon Separation
 	rules := []domain.DangerRule{
 		{"1", "1", "+"}, {"1", "2", "-"}, {"1", "3", "-"}, {"1", "4", "-"}, {"1", "5", "-"}, {"1", "6", "-"}, {"1", "7", "-"}, {"1", "8", "-"},
@@ -83,10 +179,12 @@ func (r *SQLiteRuleRepo) seedRules() {
 		{"4", "1", "-"}, {"4", "2", "+"}, {"4", "3", "+"}, {"4", "4", "+"}, {"4", "5", "1"}, {"4", "6", "+"}, {"4", "7", "+"}, {"4", "8", "+"},
 		{"5", "1", "-"}, {"5", "2", "1"}, {"5", "3", "1"}, {"5", "4", "1"}, {"5", "5", "+"}, {"5", "6", "1"}, {"5", "7", "+"}, {"5", "8", "1"},
 		{"6", "1", "-"}, {"6", "2", "+"}, {"6", "3", "+"}, {"6", "4", "+"}, {"6", "5", "1"}, {"6", "6", "+"}, {"6", "7", "+"}, {"6", "8", "+"},
+		{"7", "7", "+"},
 		{"8", "1", "-"}, {"8", "2", "+"}, {"8", "3", "+"}, {"8", "4", "+"}, {"8", "5", "1"}, {"8", "6", "+"}, {"8", "7", "+"}, {"8", "8", "+"},
+		{"9", "1", "-"}, {"9", "2", "+"}, {"9", "3", "+"}, {"9", "4", "+"}, {"9", "5", "+"}, {"9", "6", "+"}, {"9", "7", "+"}, {"9", "8", "+"}, {"9", "9", "+"},
 	}
 
-	stmt, _ := r.db.Prepare("INSERT OR REPLACE INTO danger_rules VALUES (?, ?, ?)")
+	stmt, _ := r.db.Prepare("INSERT OR IGNORE INTO danger_rules VALUES (?, ?, ?)")
 	defer stmt.Close()
 
 	for _, rule := range rules {
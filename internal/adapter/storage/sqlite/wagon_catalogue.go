@@ -0,0 +1,210 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/uic"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// catalogueAuditLimit caps the audit entries returned in one listing
+const catalogueAuditLimit = 500
+
+func (r *WagonRepository) initCatalogueAuditTable() {
+	query := `
+	CREATE TABLE IF NOT EXISTS wagon_catalogue_audit (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		changed_at DATETIME,
+		changed_by TEXT,
+		action TEXT,
+		range_from INTEGER,
+		range_to INTEGER,
+		before_json TEXT DEFAULT '',
+		after_json TEXT DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_catalogue_audit_changed ON wagon_catalogue_audit(changed_at);`
+	if _, err := r.db.Exec(query); err != nil {
+		fmt.Println("Error creating catalogue audit table:", err)
+	}
+}
+
+// ListWagonRanges groups the catalogue into runs of consecutive numbers with identical specs.
+// search matches the type or RIV code, or a number inside the range. Empty search lists all.
+func (r *WagonRepository) ListWagonRanges(search string) ([]domain.WagonRange, error) {
+	// number - ROW_NUMBER() stays constant along a run of consecutive numbers within a partition
+	query := `
+	SELECT range_from, range_to, retired, ` + wagonSpecColumns + ` FROM (
+		SELECT MIN(number) AS range_from, MAX(number) AS range_to, retired, ` + wagonSpecColumns + ` FROM (
+			SELECT *, number - ROW_NUMBER() OVER (PARTITION BY retired, ` + wagonSpecColumns + ` ORDER BY number) AS run
+			FROM wagons
+		) GROUP BY run, retired, ` + wagonSpecColumns + `
+	)`
+	var args []interface{}
+	if search = strings.TrimSpace(search); search != "" {
+		query += ` WHERE type LIKE ? OR riv_code LIKE ?`
+		args = append(args, "%"+search+"%", "%"+search+"%")
+		if n, err := strconv.Atoi(uic.Normalize(search)); err == nil {
+			if key, err := uic.CatalogueKey(n); err == nil {
+				n = key
+			}
+			query += ` OR ? BETWEEN range_from AND range_to`
+			args = append(args, n)
+		}
+	}
+	query += ` ORDER BY range_from`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []domain.WagonRange
+	for rows.Next() {
+		var rg domain.WagonRange
+		fields := append([]interface{}{&rg.From, &rg.To, &rg.Retired}, wagonSpecFields(&rg.Spec)...)
+		if err := rows.Scan(fields...); err != nil {
+			return nil, err
+		}
+		list = append(list, rg)
+	}
+	return list, rows.Err()
+}
+
+// SaveWagonRange adds a range (old == nil) or replaces old with rg, and audits the change.
+// Numbers of the new range must not already belong to another catalogue entry.
+func (r *WagonRepository) SaveWagonRange(old *domain.WagonRange, rg domain.WagonRange, changedBy string) error {
+	if err := rg.Validate(); err != nil {
+		return err
+	}
+	rg.Spec.ID, rg.Spec.Number = 0, 0
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	action := "add"
+	if old != nil {
+		action = "edit"
+		rg.Retired = old.Retired
+		if _, err := tx.Exec(`DELETE FROM wagons WHERE number BETWEEN ? AND ?`, old.From, old.To); err != nil {
+			return err
+		}
+	}
+
+	var taken int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM wagons WHERE number BETWEEN ? AND ?`, rg.From, rg.To).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return fmt.Errorf("%d number(s) between %d and %d already belong to another catalogue entry", taken, rg.From, rg.To)
+	}
+
+	stmt, err := tx.Prepare(insertWagonSQL)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for n := rg.From; n <= rg.To; n++ {
+		if _, err := stmt.Exec(append([]interface{}{n}, wagonSpecValues(rg.Spec)...)...); err != nil {
+			return err
+		}
+	}
+	if rg.Retired {
+		if _, err := tx.Exec(`UPDATE wagons SET retired = 1 WHERE number BETWEEN ? AND ?`, rg.From, rg.To); err != nil {
+			return err
+		}
+	}
+
+	if err := auditCatalogueChange(tx, changedBy, action, old, &rg); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetWagonRangeRetired retires a range, or brings a retired one back into service
+func (r *WagonRepository) SetWagonRangeRetired(rg domain.WagonRange, retired bool, changedBy string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE wagons SET retired = ? WHERE number BETWEEN ? AND ?`, retired, rg.From, rg.To); err != nil {
+		return err
+	}
+
+	action := "reactivate"
+	if retired {
+		action = "retire"
+	}
+	after := rg
+	after.Retired = retired
+	if err := auditCatalogueChange(tx, changedBy, action, &rg, &after); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListCatalogueChanges returns the audit trail, newest first
+func (r *WagonRepository) ListCatalogueChanges() ([]domain.CatalogueChange, error) {
+	rows, err := r.db.Query(`SELECT id, changed_at, changed_by, action, before_json, after_json
+		FROM wagon_catalogue_audit ORDER BY changed_at DESC, id DESC LIMIT ` + strconv.Itoa(catalogueAuditLimit))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []domain.CatalogueChange
+	for rows.Next() {
+		var c domain.CatalogueChange
+		var before, after string
+		if err := rows.Scan(&c.ID, &c.ChangedAt, &c.ChangedBy, &c.Action, &before, &after); err != nil {
+			return nil, err
+		}
+		c.Before = decodeWagonRange(before)
+		c.After = decodeWagonRange(after)
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func auditCatalogueChange(tx *sql.Tx, changedBy, action string, before, after *domain.WagonRange) error {
+	from, to := after.From, after.To
+	if before != nil && before.From < from {
+		from = before.From
+	}
+	if before != nil && before.To > to {
+		to = before.To
+	}
+	_, err := tx.Exec(`INSERT INTO wagon_catalogue_audit (changed_at, changed_by, action, range_from, range_to, before_json, after_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		time.Now(), changedBy, action, from, to, encodeWagonRange(before), encodeWagonRange(after))
+	return err
+}
+
+func encodeWagonRange(rg *domain.WagonRange) string {
+	if rg == nil {
+		return ""
+	}
+	b, _ := json.Marshal(rg)
+	return string(b)
+}
+
+func decodeWagonRange(s string) *domain.WagonRange {
+	if s == "" {
+		return nil
+	}
+	var rg domain.WagonRange
+	if json.Unmarshal([]byte(s), &rg) != nil {
+		return nil
+	}
+	return &rg
+}
@@ -0,0 +1,165 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"railguard/internal/core/domain"
+	"time"
+)
+
+// WagonUsage is the recorded state of a wagon in one saved train.
+type WagonUsage struct {
+	HistoryID            int
+	TrainNumber          string
+	CreatedAt            time.Time
+	Position             int // 1-based position among the wagons
+	IsLoaded             bool
+	IsMainBrakeHealthy   bool
+	IsHandBrakeHealthy   bool
+	IsBrakeHandleHealthy bool
+	HasDangerousGoods    bool
+	DangerousGoodsCode   string
+}
+
+// WagonStats summarises how a wagon was used across the train history.
+type WagonStats struct {
+	Number           int
+	TrainCount       int
+	LoadedCount      int
+	MainBrakeFaults  int // Times the air brake was marked unhealthy
+	HandBrakeFaults  int
+	HandleFaults     int
+	DangerousCount   int
+	Usage            []WagonUsage // Most recent first
+	UsageIsTruncated bool
+}
+
+// LastState returns the most recent recorded state, or nil if the wagon never ran.
+func (s *WagonStats) LastState() *WagonUsage {
+	if len(s.Usage) == 0 {
+		return nil
+	}
+	return &s.Usage[0]
+}
+
+// initHistoryWagonsTable creates one row per wagon per saved train and back-fills
+// entries saved before the table existed from their wagons_json.
+func (r *WagonRepository) initHistoryWagonsTable() {
+	query := `
+	CREATE TABLE IF NOT EXISTS train_history_wagons (
+		history_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		wagon_number INTEGER NOT NULL,
+		is_loaded INTEGER,
+		main_brake_ok INTEGER,
+		hand_brake_ok INTEGER,
+		brake_handle_ok INTEGER,
+		has_dangerous_goods INTEGER,
+		dangerous_goods_code TEXT,
+		PRIMARY KEY (history_id, position)
+	);
+	CREATE INDEX IF NOT EXISTS idx_history_wagons_number ON train_history_wagons(wagon_number, history_id);`
+	if _, err := r.db.Exec(query); err != nil {
+		fmt.Println("Error creating history wagons table:", err)
+		return
+	}
+
+	if err := r.backfillHistoryWagons(); err != nil {
+		fmt.Println("Error back-filling history wagons:", err)
+	}
+}
+
+func (r *WagonRepository) backfillHistoryWagons() error {
+	rows, err := r.db.Query(`SELECT id, wagons_json FROM train_history h
+		WHERE wagon_count > 0 AND NOT EXISTS (SELECT 1 FROM train_history_wagons w WHERE w.history_id = h.id)`)
+	if err != nil {
+		return err
+	}
+	pending := make(map[int][]domain.SelectedWagon)
+	for rows.Next() {
+		var id int
+		var wagonsJson string
+		if err := rows.Scan(&id, &wagonsJson); err != nil {
+			continue
+		}
+		var wagons []domain.SelectedWagon
+		if json.Unmarshal([]byte(wagonsJson), &wagons) == nil {
+			pending[id] = wagons
+		}
+	}
+	rows.Close()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	fmt.Printf("Back-filling wagon history for %d saved trains...\n", len(pending))
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for id, wagons := range pending {
+		if err := insertHistoryWagons(tx, id, wagons); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func insertHistoryWagons(tx *sql.Tx, historyID int, wagons []domain.SelectedWagon) error {
+	stmt, err := tx.Prepare(`INSERT INTO train_history_wagons (history_id, position, wagon_number, is_loaded,
+		main_brake_ok, hand_brake_ok, brake_handle_ok, has_dangerous_goods, dangerous_goods_code) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, w := range wagons {
+		_, err := stmt.Exec(historyID, i+1, w.WagonSpec.Number, w.IsLoaded,
+			w.IsMainBrakeHealthy, w.IsHandBrakeHealthy, w.IsBrakeHandleHealthy, w.HasDangerousGoods, w.DangerousGoodsCode)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetWagonStats returns the usage of a wagon across saved trains.
+// Counters cover the whole history; Usage holds at most limit entries.
+func (r *WagonRepository) GetWagonStats(number, limit int) (*WagonStats, error) {
+	stats := &WagonStats{Number: number}
+	err := r.db.QueryRow(`SELECT COUNT(DISTINCT history_id),
+		COALESCE(SUM(is_loaded), 0),
+		COALESCE(SUM(NOT main_brake_ok), 0),
+		COALESCE(SUM(NOT hand_brake_ok), 0),
+		COALESCE(SUM(NOT brake_handle_ok), 0),
+		COALESCE(SUM(has_dangerous_goods), 0)
+		FROM train_history_wagons WHERE wagon_number = ?`, number).Scan(
+		&stats.TrainCount, &stats.LoadedCount, &stats.MainBrakeFaults, &stats.HandBrakeFaults, &stats.HandleFaults, &stats.DangerousCount)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`SELECT h.id, h.train_number, h.created_at, w.position, w.is_loaded,
+		w.main_brake_ok, w.hand_brake_ok, w.brake_handle_ok, w.has_dangerous_goods, w.dangerous_goods_code
+		FROM train_history_wagons w JOIN train_history h ON h.id = w.history_id
+		WHERE w.wagon_number = ? ORDER BY h.created_at DESC, h.id DESC LIMIT ?`, number, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u WagonUsage
+		err := rows.Scan(&u.HistoryID, &u.TrainNumber, &u.CreatedAt, &u.Position, &u.IsLoaded,
+			&u.IsMainBrakeHealthy, &u.IsHandBrakeHealthy, &u.IsBrakeHandleHealthy, &u.HasDangerousGoods, &u.DangerousGoodsCode)
+		if err != nil {
+			return nil, err
+		}
+		stats.Usage = append(stats.Usage, u)
+	}
+	stats.UsageIsTruncated = len(stats.Usage) < stats.TrainCount
+	return stats, rows.Err()
+}
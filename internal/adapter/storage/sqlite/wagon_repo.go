@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"railguard/internal/core/domain"
+	"railguard/internal/core/uic"
+	"railguard/internal/core/units"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -28,6 +30,8 @@ func NewWagonRepository(dbPath string) (*WagonRepository, error) {
 	// Seed Data if empty
 	repo.seedDefaultData()
 	repo.initHistoryTable()
+	repo.initHistoryWagonsTable()
+	repo.initCatalogueAuditTable()
 	return repo, nil
 }
 
@@ -62,29 +66,84 @@ func (r *WagonRepository) initDB() error {
 		brake_cylinder TEXT,
 		coupling_type TEXT
 	);`
-	_, err := r.db.Exec(query)
-	return err
+	if _, err := r.db.Exec(query); err != nil {
+		return err
+	}
+
+	// Retired wagons stay in the table so old compositions still resolve
+	return ensureColumns(r.db, "wagons", []column{
+		{"retired", "INTEGER DEFAULT 0"},
+	})
 }
 
-// GetWagonByNumber retrieves full details
-func (r *WagonRepository) GetWagonByNumber(number int) (*domain.Wagon, error) {
-	query := `SELECT * FROM wagons WHERE number = ?`
-	row := r.db.QueryRow(query, number)
+// wagonSpecColumns lists the specification columns in the order scanWagonSpec reads them
+const wagonSpecColumns = `type, axles, weight_empty, weight_loaded, max_capacity, load_volume,
+	brake_weight_empty, brake_weight_loaded, length, load_length, load_width, floor_height, internal_height,
+	bogie_pivot_dist, wheel_diameter, riv_code, manufacturer, year, bogie_type, bearing_type, spring_type,
+	hand_brake_type, hand_brake_weight, control_valve, brake_cylinder, coupling_type`
 
-	var w domain.Wagon
-	// Note: The order must match the CREATE TABLE columns EXACTLY
-	err := row.Scan(
-		&w.Number, &w.Type, &w.Axles,
+// The wagons table keeps masses in tonnes and lengths in metres, which scan
+// straight into units.Mass and units.Length. Wheel diameters are kept in mm.
+
+// millimetreColumn scans a column kept in mm into a Length
+type millimetreColumn struct{ l *units.Length }
+
+func (c millimetreColumn) Scan(src interface{}) error {
+	var mm sql.NullFloat64
+	if err := mm.Scan(src); err != nil {
+		return err
+	}
+	*c.l = units.Millimetres(mm.Float64)
+	return nil
+}
+
+// wagonSpecFields returns pointers to w's fields in wagonSpecColumns order, for Scan
+func wagonSpecFields(w *domain.Wagon) []interface{} {
+	return []interface{}{
+		&w.Type, &w.Axles,
 		&w.WeightEmpty, &w.WeightLoaded, &w.MaxCapacity, &w.LoadVolume,
 		&w.BrakeWeightEmpty, &w.BrakeWeightLoaded,
 		&w.Length, &w.LoadLength, &w.LoadWidth, &w.FloorHeight, &w.InternalHeight,
-		&w.BogiePivotDistance, &w.WheelDiameter,
+		&w.BogiePivotDistance, millimetreColumn{&w.WheelDiameter},
 		&w.RIVCode, &w.Manufacturer, &w.Year, &w.BogieType, &w.BearingType, &w.SpringType,
 		&w.HandBrakeType, &w.HandBrakeWeight, &w.ControlValveType, &w.BrakeCylinderType, &w.CouplingType,
-	)
+	}
+}
+
+// wagonSpecValues returns w's fields in wagonSpecColumns order, for Exec
+func wagonSpecValues(w domain.Wagon) []interface{} {
+	return []interface{}{
+		w.Type, w.Axles,
+		w.WeightEmpty.Tonnes(), w.WeightLoaded.Tonnes(), w.MaxCapacity.Tonnes(), w.LoadVolume,
+		w.BrakeWeightEmpty.Tonnes(), w.BrakeWeightLoaded.Tonnes(),
+		w.Length.Metres(), w.LoadLength.Metres(), w.LoadWidth.Metres(), w.FloorHeight.Metres(), w.InternalHeight.Metres(),
+		w.BogiePivotDistance.Metres(), w.WheelDiameter.Millimetres(),
+		w.RIVCode, w.Manufacturer, w.Year, w.BogieType, w.BearingType, w.SpringType,
+		w.HandBrakeType, w.HandBrakeWeight.Tonnes(), w.ControlValveType, w.BrakeCylinderType, w.CouplingType,
+	}
+}
+
+// insertWagonSQL adds one catalogue number; the arguments are the number followed by wagonSpecValues
+const insertWagonSQL = `INSERT OR IGNORE INTO wagons (number, ` + wagonSpecColumns + `)
+	VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`
+
+// GetWagonByNumber retrieves full details. Retired wagons are not found.
+// number is a national number or a full UIC number, whose check digit must match.
+func (r *WagonRepository) GetWagonByNumber(number int) (*domain.Wagon, error) {
+	key, err := uic.CatalogueKey(number)
 	if err != nil {
 		return nil, err
 	}
+	query := `SELECT number, ` + wagonSpecColumns + ` FROM wagons WHERE number = ? AND retired = 0`
+	row := r.db.QueryRow(query, key)
+
+	var w domain.Wagon
+	err = row.Scan(append([]interface{}{&w.Number}, wagonSpecFields(&w)...)...)
+	if err != nil {
+		return nil, err
+	}
+	// Compositions record the number as written on the wagon, check digit included
+	w.Number = number
 	return &w, nil
 }
 
@@ -101,22 +160,16 @@ func (r *WagonRepository) seedDefaultData() {
 
 	// Helper to insert a range of wagons
 	insertRange := func(from, to int, w domain.Wagon) {
-		stmt, _ := r.db.Prepare(`INSERT OR IGNORE INTO wagons VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`)
+		stmt, _ := r.db.Prepare(insertWagonSQL)
 		defer stmt.Close()
 
 		for i := from; i <= to; i++ {
-			stmt.Exec(
-				i, w.Type, w.Axles,
-				w.WeightEmpty, w.WeightLoaded, w.MaxCapacity, w.LoadVolume,
-				w.BrakeWeightEmpty, w.BrakeWeightLoaded,
-				w.Length, w.LoadLength, w.LoadWidth, w.FloorHeight, w.InternalHeight,
-				w.BogiePivotDistance, w.WheelDiameter,
-				w.RIVCode, w.Manufacturer, w.Year, w.BogieType, w.BearingType, w.SpringType,
-				w.HandBrakeType, w.HandBrakeWeight, w.ControlValveType, w.BrakeCylinderType, w.CouplingType,
-			)
+			stmt.Exec(append([]interface{}{i}, wagonSpecValues(w)...)...)
 		}
 	}
 
+	// Weights in t and lengths in m, wheel diameters as given on the wagon in mm
+
 	// 1. Car Carrier (Romania) - 140001 to 140168
 	w1 := domain.Wagon{
 		Type: "ویژه حمل خودرو", Axles: 3, Length: 27.0, LoadLength: 25.7, LoadWidth: 2.68,
@@ -126,7 +179,7 @@ func (r *WagonRepository) seedDefaultData() {
 		BearingType: "سیلندریکال(80*240*120)", BogieType: "تک محور", SpringType: "قوس منفی 120",
 		HandBrakeType: "پیچی جانبی", HandBrakeWeight: 20.1,
 		ControlValveType: "KE1CSL", BrakeCylinderType: "DRV2AT-600", CouplingType: "زنجیری",
-		FloorHeight: 1.17, InternalHeight: 4.35, WheelDiameter: 840,
+		FloorHeight: 1.17, InternalHeight: 4.35, WheelDiameter: 840 * units.Millimetre,
 	}
 	insertRange(140001, 140168, w1)
 
@@ -159,7 +212,7 @@ func (r *WagonRepository) seedDefaultData() {
 		BearingType: "سیلندریکال", BogieType: "H655", SpringType: "قوس منفی 120",
 		HandBrakeType: "پیچی در ایوان", HandBrakeWeight: 24,
 		ControlValveType: "KE1CSL", BrakeCylinderType: "DRV2A-600", CouplingType: "یونی کوپلر",
-		BogiePivotDistance: 11.5, FloorHeight: 1.2, InternalHeight: 3.07, WheelDiameter: 920,
+		BogiePivotDistance: 11.5, FloorHeight: 1.2, InternalHeight: 3.07, WheelDiameter: 920 * units.Millimetre,
 	}
 	insertRange(147001, 147600, w3)
 
@@ -171,14 +224,16 @@ func (r *WagonRepository) GetDB() *sql.DB {
 
 type HistoryItem struct {
 	ID          int
-	TrainNumber string
-	DriverName  string
+	Trip        domain.TripInfo
 	CreatedAt   time.Time
-	Slope       int
-	TotalWeight float64
-	MaxSpeed    int
+	Slope       units.Gradient
+	TotalWeight units.Mass
+	MaxSpeed    units.Speed
 	Locos       []domain.Locomotive
 	Wagons      []domain.SelectedWagon
+	Result      *domain.CalculationResult // nil for entries saved before results were stored
+	Validation  *domain.ValidationResult
+	WagonCount  int
 }
 
 // Initialize the history table
@@ -198,23 +253,155 @@ func (r *WagonRepository) initHistoryTable() {
 	_, err := r.db.Exec(query)
 	if err != nil {
 		fmt.Println("Error creating history table:", err)
+		return
+	}
+
+	// Full trip info and results, added after the first release of the table
+	err = ensureColumns(r.db, "train_history", []column{
+		{"origin", "TEXT DEFAULT ''"},
+		{"destination", "TEXT DEFAULT ''"},
+		{"train_boss", "TEXT DEFAULT ''"},
+		{"trip_date", "TEXT DEFAULT ''"},
+		{"trip_time", "TEXT DEFAULT ''"},
+		{"result_json", "TEXT DEFAULT ''"},
+		{"validation_json", "TEXT DEFAULT ''"},
+		{"updated_at", "DATETIME"},
+		{"is_safe", "INTEGER"},
+		{"wagon_count", "INTEGER"},
+	})
+	if err != nil {
+		fmt.Println("Error migrating history table:", err)
+		return
+	}
+
+	// Back-fill the filter columns of older entries and index what the history screen filters on
+	_, err = r.db.Exec(`
+	UPDATE train_history SET is_safe = json_extract(result_json, '$.is_safe') WHERE is_safe IS NULL AND result_json != '';
+	UPDATE train_history SET wagon_count = json_array_length(wagons_json) WHERE wagon_count IS NULL AND json_valid(wagons_json);
+	CREATE INDEX IF NOT EXISTS idx_history_created ON train_history(created_at);
+	CREATE INDEX IF NOT EXISTS idx_history_train ON train_history(train_number);
+	CREATE INDEX IF NOT EXISTS idx_history_driver ON train_history(driver_name);
+	CREATE INDEX IF NOT EXISTS idx_history_safe ON train_history(is_safe, created_at);`)
+	if err != nil {
+		fmt.Println("Error indexing history table:", err)
+	}
+}
+
+const historyColumns = `id, train_number, driver_name, created_at, slope, total_weight, max_speed, locos_json, wagons_json,
+	origin, destination, train_boss, trip_date, trip_time, result_json, validation_json`
+
+// SaveTrainComposition saves the current setup to DB as a new entry and returns its ID
+func (r *WagonRepository) SaveTrainComposition(h HistoryItem) (int, error) {
+	locosBytes, _ := json.Marshal(h.Locos)
+	wagonsBytes, _ := json.Marshal(h.Wagons)
+	resultJson := marshalOptional(h.Result)
+	validationJson := marshalOptional(h.Validation)
+
+	query := `INSERT INTO train_history (train_number, driver_name, created_at, slope, total_weight, max_speed, locos_json, wagons_json,
+		origin, destination, train_boss, trip_date, trip_time, result_json, validation_json, is_safe, wagon_count) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	t := h.Trip
+	res, err := tx.Exec(query, t.TrainNumber, t.DriverName, time.Now(), h.Slope.Permil(), h.TotalWeight.Tonnes(), h.MaxSpeed.KmPerHour(), string(locosBytes), string(wagonsBytes),
+		t.Origin, t.Destination, t.TrainBossName, t.Date, t.Time, resultJson, validationJson, safeFlag(h.Result), len(h.Wagons))
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := insertHistoryWagons(tx, int(id), h.Wagons); err != nil {
+		return 0, err
 	}
+	return int(id), tx.Commit()
 }
 
-// SaveTrainComposition saves the current setup to DB
-func (r *WagonRepository) SaveTrainComposition(h HistoryItem) error {
+// UpdateTrainComposition overwrites an existing entry with a changed setup
+func (r *WagonRepository) UpdateTrainComposition(h HistoryItem) error {
 	locosBytes, _ := json.Marshal(h.Locos)
 	wagonsBytes, _ := json.Marshal(h.Wagons)
 
-	query := `INSERT INTO train_history (train_number, driver_name, created_at, slope, total_weight, max_speed, locos_json, wagons_json) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `UPDATE train_history SET train_number = ?, driver_name = ?, slope = ?, total_weight = ?, max_speed = ?, locos_json = ?, wagons_json = ?,
+		origin = ?, destination = ?, train_boss = ?, trip_date = ?, trip_time = ?, result_json = ?, validation_json = ?, updated_at = ?,
+		is_safe = ?, wagon_count = ? WHERE id = ?`
 
-	_, err := r.db.Exec(query, h.TrainNumber, h.DriverName, time.Now(), h.Slope, h.TotalWeight, h.MaxSpeed, string(locosBytes), string(wagonsBytes))
-	return err
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	t := h.Trip
+	res, err := tx.Exec(query, t.TrainNumber, t.DriverName, h.Slope.Permil(), h.TotalWeight.Tonnes(), h.MaxSpeed.KmPerHour(), string(locosBytes), string(wagonsBytes),
+		t.Origin, t.Destination, t.TrainBossName, t.Date, t.Time, marshalOptional(h.Result), marshalOptional(h.Validation), time.Now(),
+		safeFlag(h.Result), len(h.Wagons), h.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.Exec("DELETE FROM train_history_wagons WHERE history_id = ?", h.ID); err != nil {
+		return err
+	}
+	if err := insertHistoryWagons(tx, h.ID, h.Wagons); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateHistoryTrip changes only the trip info (train number, driver, ...) of an entry
+func (r *WagonRepository) UpdateHistoryTrip(id int, t domain.TripInfo) error {
+	query := `UPDATE train_history SET train_number = ?, driver_name = ?, origin = ?, destination = ?, train_boss = ?, trip_date = ?, trip_time = ?, updated_at = ? WHERE id = ?`
+	return r.execOne(query, t.TrainNumber, t.DriverName, t.Origin, t.Destination, t.TrainBossName, t.Date, t.Time, time.Now(), id)
+}
+
+// DeleteHistory removes an entry together with its wagon rows
+func (r *WagonRepository) DeleteHistory(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM train_history_wagons WHERE history_id = ?", id); err != nil {
+		return err
+	}
+	res, err := tx.Exec("DELETE FROM train_history WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+// DuplicateHistory copies an entry, e.g. to use it as a template, and returns the new ID
+func (r *WagonRepository) DuplicateHistory(id int) (int, error) {
+	h, err := r.GetHistory(id)
+	if err != nil {
+		return 0, err
+	}
+	return r.SaveTrainComposition(*h)
+}
+
+// GetHistory retrieves a single saved train
+func (r *WagonRepository) GetHistory(id int) (*HistoryItem, error) {
+	row := r.db.QueryRow("SELECT "+historyColumns+" FROM train_history WHERE id = ?", id)
+	return scanHistory(row)
 }
 
 // GetAllHistory retrieves the list of saved trains
 func (r *WagonRepository) GetAllHistory() ([]HistoryItem, error) {
-	rows, err := r.db.Query("SELECT id, train_number, driver_name, created_at, slope, total_weight, max_speed, locos_json, wagons_json FROM train_history ORDER BY id DESC")
+	rows, err := r.db.Query("SELECT " + historyColumns + " FROM train_history ORDER BY id DESC")
 	if err != nil {
 		return nil, err
 	}
@@ -222,19 +409,68 @@ func (r *WagonRepository) GetAllHistory() ([]HistoryItem, error) {
 
 	var history []HistoryItem
 	for rows.Next() {
-		var h HistoryItem
-		var locosJson, wagonsJson string
-
-		err := rows.Scan(&h.ID, &h.TrainNumber, &h.DriverName, &h.CreatedAt, &h.Slope, &h.TotalWeight, &h.MaxSpeed, &locosJson, &wagonsJson)
+		h, err := scanHistory(rows)
 		if err != nil {
 			continue
 		}
+		history = append(history, *h)
+	}
+	return history, nil
+}
 
-		// Unmarshal JSON back to Go structs
-		json.Unmarshal([]byte(locosJson), &h.Locos)
-		json.Unmarshal([]byte(wagonsJson), &h.Wagons)
+// execOne runs an UPDATE/DELETE that must hit exactly one row
+func (r *WagonRepository) execOne(query string, args ...interface{}) error {
+	res, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// scanHistory reads one row selected with historyColumns
+func scanHistory(row interface{ Scan(...interface{}) error }) (*HistoryItem, error) {
+	var h HistoryItem
+	var locosJson, wagonsJson, resultJson, validationJson string
+	t := &h.Trip
 
-		history = append(history, h)
+	err := row.Scan(&h.ID, &t.TrainNumber, &t.DriverName, &h.CreatedAt, &h.Slope, &h.TotalWeight, &h.MaxSpeed, &locosJson, &wagonsJson,
+		&t.Origin, &t.Destination, &t.TrainBossName, &t.Date, &t.Time, &resultJson, &validationJson)
+	if err != nil {
+		return nil, err
 	}
-	return history, nil
+
+	// Unmarshal JSON back to Go structs
+	json.Unmarshal([]byte(locosJson), &h.Locos)
+	json.Unmarshal([]byte(wagonsJson), &h.Wagons)
+	domain.ClearStaleGoods(h.Wagons)
+	h.WagonCount = len(h.Wagons)
+	if resultJson != "" {
+		h.Result = &domain.CalculationResult{}
+		json.Unmarshal([]byte(resultJson), h.Result)
+	}
+	if validationJson != "" {
+		h.Validation = &domain.ValidationResult{}
+		json.Unmarshal([]byte(validationJson), h.Validation)
+	}
+	return &h, nil
+}
+
+// safeFlag is the value of the is_safe column: NULL when no result was stored
+func safeFlag(res *domain.CalculationResult) interface{} {
+	if res == nil {
+		return nil
+	}
+	return res.IsSafe
+}
+
+// marshalOptional encodes v as JSON, or "" when v is nil
+func marshalOptional[T any](v *T) string {
+	if v == nil {
+		return ""
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
 }
@@ -0,0 +1,39 @@
+package domain
+
+import "railguard/internal/core/units"
+
+// BrakeSuggestionKind says what a suggestion changes in the composition.
+type BrakeSuggestionKind string
+
+const (
+	SuggestCutInBrake BrakeSuggestionKind = "cut_in" // Repair or cut in an isolated air brake
+	SuggestDetach     BrakeSuggestionKind = "detach" // Detach a wagon braked below the required percentage
+	SuggestAddBraked  BrakeSuggestionKind = "add"    // Add braked weight, e.g. a braked vehicle or locomotive
+)
+
+// BrakeSuggestion is one change that raises the brake percentage of a train.
+type BrakeSuggestion struct {
+	Kind        BrakeSuggestionKind `json:"kind"`
+	WagonNumber int                 `json:"wagon_number,omitempty"`
+	Position    int                 `json:"position,omitempty"` // 1-based position of the wagon in the train
+	BrakeWeight units.Mass          `json:"brake_weight"`       // Brake weight gained, or needed for SuggestAddBraked
+	Percentage  int                 `json:"percentage"`         // Brake percentage of the train with only this change
+	Closes      bool                `json:"closes"`             // This change alone reaches the required percentage
+	Text        string              `json:"text"`
+}
+
+// BrakeRequirement answers how much braking a train needs for a booked speed.
+type BrakeRequirement struct {
+	Slope              units.Gradient    `json:"slope"`
+	Speed              units.Speed       `json:"speed"` // Booked line speed
+	Achievable         bool              `json:"achievable"`
+	RequiredPercentage int               `json:"required_percentage"`
+	CurrentPercentage  int               `json:"current_percentage"`
+	MissingBrakeWeight units.Mass        `json:"missing_brake_weight"` // 0 when the train already meets the requirement
+	Suggestions        []BrakeSuggestion `json:"suggestions"`          // Most effective first
+}
+
+// Met reports whether the train already brakes enough for the booked speed.
+func (r BrakeRequirement) Met() bool {
+	return r.Achievable && r.CurrentPercentage >= r.RequiredPercentage
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "railguard/internal/core/units"
+
+// BrakeRegime is the position of the wagons' G/P change-over device.
+type BrakeRegime string
+
+const (
+	RegimeGoods     BrakeRegime = "G" // Slow-acting, for long freight trains
+	RegimePassenger BrakeRegime = "P" // Fast-acting
+)
+
+// Label is the human readable name used on screen and in reports.
+func (r BrakeRegime) Label() string {
+	switch r {
+	case RegimeGoods:
+		return "G (Goods)"
+	case RegimePassenger:
+		return "P (Passenger)"
+	}
+	return string(r)
+}
+
+// BrakingPoint is the expected stop from one initial speed.
+type BrakingPoint struct {
+	Speed    units.Speed  `json:"speed"`    // Initial speed
+	Distance units.Length `json:"distance"` // Stopping distance, build-up included
+	Time     float64      `json:"time"`     // Seconds from brake application to standstill
+}
+
+// BrakingCurve is the stopping distance of a train over its speed range
+// on one gradient, for one brake regime.
+type BrakingCurve struct {
+	Regime       BrakeRegime    `json:"regime"`
+	Gradient     units.Gradient `json:"gradient"`      // Falling gradient (negative = rising)
+	Deceleration float64        `json:"deceleration"`  // Net deceleration with the brakes fully applied, m/s²
+	BuildUpTime  float64        `json:"build_up_time"` // Equivalent dead time until full braking, seconds
+	CanStop      bool           `json:"can_stop"`      // False when the gradient overcomes the brakes
+	Points       []BrakingPoint `json:"points"`
+}
+
+// DistanceAt returns the stopping distance from speed, interpolated between the
+// points of the curve. ok is false outside the curve or when the train cannot stop.
+func (c BrakingCurve) DistanceAt(speed units.Speed) (distance units.Length, ok bool) {
+	if !c.CanStop {
+		return 0, false
+	}
+	for i := 1; i < len(c.Points); i++ {
+		p0, p1 := c.Points[i-1], c.Points[i]
+		if speed >= p0.Speed && speed <= p1.Speed {
+			t := float64((speed - p0.Speed) / (p1.Speed - p0.Speed))
+			return p0.Distance + units.Length(t)*(p1.Distance-p0.Distance), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "railguard/internal/core/units"
+
+// CompositionSnapshot is one side of a composition comparison.
+type CompositionSnapshot struct {
+	Locos  []Locomotive
+	Wagons []SelectedWagon
+	Slope  units.Gradient
+
+	// Where the composition came from; not compared
+	HistoryID int // History entry it is saved as (0 = not saved yet)
+	Trip      TripInfo
+}
+
+// WagonChange describes what happened to one wagon between two compositions.
+// Positions are 1-based within the wagon list; 0 means the wagon is absent.
+type WagonChange struct {
+	Number  int
+	OldPos  int
+	NewPos  int
+	Details []string // Changed inputs, e.g. "empty → loaded"
+}
+
+// CompositionDiff is the result of comparing two saved compositions.
+type CompositionDiff struct {
+	Added   []WagonChange
+	Removed []WagonChange
+	Moved   []WagonChange // Wagons whose order relative to the others changed
+	Changed []WagonChange // Wagons with a different load or brake state
+
+	OldResult *CalculationResult
+	NewResult *CalculationResult
+
+	WeightDelta          units.Mass
+	BrakeWeightDelta     units.Mass
+	BrakePercentageDelta int
+	MaxSpeedDelta        units.Speed
+}
+
+// IsEmpty reports whether both compositions have the same wagons in the same state and order.
+func (d *CompositionDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Moved) == 0 && len(d.Changed) == 0
+}
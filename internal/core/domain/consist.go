@@ -0,0 +1,20 @@
+package domain
+
+// ConsistWarningKind names a risky load distribution pattern.
+type ConsistWarningKind string
+
+const (
+	ConsistHeavyBehindLight ConsistWarningKind = "heavy_behind_light" // A string of light wagons with heavy mass behind it
+	ConsistMassJump         ConsistWarningKind = "mass_jump"          // A much heavier wagon right behind a light one
+	ConsistEmptyRear        ConsistWarningKind = "empty_rear"         // Empty wagons at the rear of a heavy train
+)
+
+// ConsistWarning is an advisory about the wagon order. It does not affect the
+// safety verdict of the calculation.
+type ConsistWarning struct {
+	Kind ConsistWarningKind `json:"kind"`
+	From int                `json:"from"` // 1-based wagon positions the warning covers
+	To   int                `json:"to"`
+	Text string             `json:"text"`
+	Fix  string             `json:"fix"` // Suggested change of order
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "railguard/internal/core/units"
+
+// CouplerLoad is the estimated force on one coupling of the train.
+type CouplerLoad struct {
+	Position     int        `json:"position"`      // 1-based wagon position behind the coupling, 0 for a locomotive
+	Behind       string     `json:"behind"`        // Vehicle behind the coupling, e.g. "wagon #123456"
+	CouplingType string     `json:"coupling_type"` // Weaker coupling type of the two vehicles
+	TrailingMass units.Mass `json:"trailing_mass"` // Behind the coupling
+	Force        float64    `json:"force"`         // kN when starting at full tractive effort
+}
+
+// CouplerCheck compares the coupling forces with the limit of the weakest
+// coupling type in the train.
+type CouplerCheck struct {
+	WeakestType  string        `json:"weakest_type"`
+	Limit        float64       `json:"limit"`                   // kN permitted drawbar load of the weakest type
+	MaxForce     float64       `json:"max_force"`               // kN, at the first trailing coupling
+	Loads        []CouplerLoad `json:"loads"`                   // Every coupling behind the hot locomotives, front to rear
+	AtRisk       []CouplerLoad `json:"at_risk"`                 // Couplings loaded beyond Limit
+	UnknownTypes []string      `json:"unknown_types,omitempty"` // Coupling types without a known limit, checked as screw couplings
+}
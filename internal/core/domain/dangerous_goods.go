@@ -0,0 +1,89 @@
+package domain
+
+import "strings"
+
+// DangerousGood is an entry of the dangerous goods list, keyed by UN number.
+type DangerousGood struct {
+	UNNumber           string `json:"un_number"`           // Four digits, e.g. "1203"
+	ProperName         string `json:"proper_name"`         // Proper shipping name
+	Class              string `json:"class"`               // RID class, e.g. "3" or "6.1"
+	ClassificationCode string `json:"classification_code"` // e.g. "F1"
+	PackingGroup       string `json:"packing_group"`       // "I", "II", "III" or empty
+	HazardNumber       string `json:"hazard_number"`       // Hazard identification number, e.g. "33"
+	SpecialProvisions  string `json:"special_provisions"`  // RID special provisions, space separated
+}
+
+// SegregationCode is the code the compatibility matrix is keyed by: the main class.
+func (g DangerousGood) SegregationCode() string {
+	code, _, _ := strings.Cut(g.Class, ".")
+	return code
+}
+
+// Label is the short form used on screen, e.g. "UN1203 Petrol (3)".
+func (g DangerousGood) Label() string {
+	return "UN" + g.UNNumber + " " + g.ProperName + " (" + g.Class + ")"
+}
+
+// DangerousCargo is one dangerous goods entry of a wagon's load,
+// e.g. one compartment of a tank wagon.
+type DangerousCargo struct {
+	Code     string         `json:"code"`            // Class code, the class of Goods when it is set
+	Goods    *DangerousGood `json:"goods,omitempty"` // List entry of the UN number (nil = bare class code)
+	Quantity float64        `json:"quantity"`        // kg or litres, as on the shipping documents
+}
+
+// SegregationCode is the matrix code of the entry, derived from the UN number
+// when known. The matrix is keyed by main class, so a bare class code such as
+// "3a", "4-1" or "6-1 HCN" counts as its main class.
+func (c DangerousCargo) SegregationCode() string {
+	if c.Goods != nil {
+		return c.Goods.SegregationCode()
+	}
+	fields := strings.Fields(c.Code)
+	if len(fields) == 0 {
+		return ""
+	}
+	class, _, _ := strings.Cut(strings.TrimRight(fields[0], "abcdefghijklmnopqrstuvwxyz"), "-")
+	return class
+}
+
+// Label names the entry for findings and reports.
+func (c DangerousCargo) Label() string {
+	if c.Goods != nil {
+		return "UN" + c.Goods.UNNumber + " class " + c.Goods.Class
+	}
+	return c.Code
+}
+
+// DangerousCargo returns the wagon's dangerous goods entries. Compositions saved
+// before wagons held several entries are read as one entry with their code.
+func (w SelectedWagon) DangerousCargo() []DangerousCargo {
+	if !w.HasDangerousGoods {
+		return nil
+	}
+	if len(w.Cargo) > 0 {
+		return w.Cargo
+	}
+	return []DangerousCargo{{Code: w.DangerousGoodsCode}}
+}
+
+// SetCargo stores the entries and keeps the summary fields in step.
+func (w *SelectedWagon) SetCargo(cargo []DangerousCargo) {
+	w.Cargo = cargo
+	w.HasDangerousGoods = len(cargo) > 0
+	codes := make([]string, len(cargo))
+	for i, c := range cargo {
+		codes[i] = c.Code
+	}
+	w.DangerousGoodsCode = strings.Join(codes, "+")
+}
+
+// GoodsLabel names the wagon's dangerous cargo for findings and reports.
+func (w SelectedWagon) GoodsLabel() string {
+	cargo := w.DangerousCargo()
+	labels := make([]string, len(cargo))
+	for i, c := range cargo {
+		labels[i] = c.Label()
+	}
+	return strings.Join(labels, " + ")
+}
@@ -0,0 +1,58 @@
+package domain
+
+import "time"
+
+// DefectComponent names the part of a wagon a defect was reported against.
+type DefectComponent string
+
+const (
+	DefectMainBrake   DefectComponent = "main_brake"
+	DefectHandBrake   DefectComponent = "hand_brake"
+	DefectBrakeHandle DefectComponent = "brake_handle"
+)
+
+// Label is the human readable name used on screen and in exports.
+func (c DefectComponent) Label() string {
+	switch c {
+	case DefectMainBrake:
+		return "Air Brake"
+	case DefectHandBrake:
+		return "Hand Brake"
+	case DefectBrakeHandle:
+		return "Brake Handle"
+	}
+	return string(c)
+}
+
+// Defect is an entry of the defective wagon register.
+// It stays open until a workshop closes it with a note.
+type Defect struct {
+	ID          int
+	WagonNumber int
+	Component   DefectComponent
+	TrainNumber string // Train the defect was last reported in
+	ReportCount int    // How many times the defect was reported while open
+	OpenedAt    time.Time
+	LastSeenAt  time.Time
+	ClosedAt    time.Time // Zero while the defect is open
+	CloseNote   string
+}
+
+func (d Defect) IsOpen() bool {
+	return d.ClosedAt.IsZero()
+}
+
+// Defects lists the brake components a composition entry was marked unhealthy for.
+func (w SelectedWagon) Defects() []DefectComponent {
+	var list []DefectComponent
+	if !w.IsMainBrakeHealthy {
+		list = append(list, DefectMainBrake)
+	}
+	if !w.IsHandBrakeHealthy {
+		list = append(list, DefectHandBrake)
+	}
+	if !w.IsBrakeHandleHealthy {
+		list = append(list, DefectBrakeHandle)
+	}
+	return list
+}
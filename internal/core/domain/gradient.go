@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"railguard/internal/core/units"
+)
+
+// GradientPoint marks where the track gradient changes, in the direction of travel.
+type GradientPoint struct {
+	Chainage units.Length   `json:"chainage"` // From the start of the route
+	Gradient units.Gradient `json:"gradient"` // From here to the next point, falling positive
+}
+
+// GradientProfile is the gradient of a route over its length. The gradient of
+// the last point is unused, its chainage ends the route.
+type GradientProfile struct {
+	Name   string          `json:"name"`
+	Points []GradientPoint `json:"points"`
+}
+
+// Validate checks the profile has at least one section and rising chainages.
+func (p GradientProfile) Validate() error {
+	if len(p.Points) < 2 {
+		return errors.New("a gradient profile needs at least two points")
+	}
+	for i := 1; i < len(p.Points); i++ {
+		if p.Points[i].Chainage <= p.Points[i-1].Chainage {
+			return fmt.Errorf("chainage %.3f km must be after %.3f km", p.Points[i].Chainage.Kilometres(), p.Points[i-1].Chainage.Kilometres())
+		}
+	}
+	return nil
+}
+
+// SteepestFalling returns the steepest falling gradient between two chainages,
+// limited to the route. A route that only rises returns its gentlest rise.
+func (p GradientProfile) SteepestFalling(from, to units.Length) units.Gradient {
+	steepest := units.Gradient(math.Inf(-1))
+	for i := 0; i+1 < len(p.Points); i++ {
+		if p.Points[i+1].Chainage > from && p.Points[i].Chainage < to {
+			steepest = max(steepest, p.Points[i].Gradient)
+		}
+	}
+	return steepest
+}
+
+// SteepestRising returns the steepest rising gradient of the route as a positive
+// gradient, 0 for a route that never rises.
+func (p GradientProfile) SteepestRising() units.Gradient {
+	var rising units.Gradient
+	for i := 0; i+1 < len(p.Points); i++ {
+		rising = max(rising, -p.Points[i].Gradient)
+	}
+	return rising
+}
+
+// RouteSection is the brake calculation result of one section of a gradient profile.
+type RouteSection struct {
+	From             units.Length   `json:"from"`
+	To               units.Length   `json:"to"`
+	Gradient         units.Gradient `json:"gradient"`  // Of the section itself
+	Governing        units.Gradient `json:"governing"` // Steepest falling gradient over the section and the braking distance beyond it
+	MaxSpeed         units.Speed    `json:"max_speed"`
+	StoppingDistance units.Length   `json:"stopping_distance"` // From MaxSpeed on the governing gradient
+}
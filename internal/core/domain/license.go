@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// LicenseRecord is the archive entry written for every generated brake license.
+type LicenseRecord struct {
+	ID           int
+	Trip         TripInfo
+	Sequence     int // Running number of licenses for this train on the issue day
+	FileName     string
+	Location     string // URI the PDF was written to
+	FileHash     string // SHA-256 of the PDF file
+	IssuedAt     time.Time
+	Result       CalculationResult
+	WagonNumbers []int
+	Payload      []byte // license.json as embedded in the PDF (composition snapshot)
+	Signature    []byte // signature.json as embedded in the PDF (nil = unsigned)
+}
+
+// LicenseFilter narrows an archive search. Zero values match everything.
+type LicenseFilter struct {
+	TrainNumber string
+	DriverName  string
+	From        time.Time
+	To          time.Time
+	WagonNumber int
+}
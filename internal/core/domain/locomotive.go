@@ -1,10 +1,58 @@
 package domain
 
+import (
+	"fmt"
+	"railguard/internal/core/units"
+	"strings"
+)
+
 // Locomotive represents the engine of the train.
 type Locomotive struct {
-	ID          string  `json:"id"`           // e.g., "GM-1", "Alstom"
-	Number      int     `json:"number"`       // e.g., 2065
-	Weight      float64 `json:"weight"`       // Weight in tons (e.g., 120 tons)
-	BrakeWeight float64 `json:"brake_weight"` // Brake power in tons
-	IsHot       bool    `json:"is_hot"`       // True = Active (Pulling), False = Dead (Towed)
+	ID          string     `json:"id"`           // e.g., "GM-1", "Alstom"
+	Number      int        `json:"number"`       // e.g., 2065
+	Weight      units.Mass `json:"weight"`       // e.g., 120 t
+	BrakeWeight units.Mass `json:"brake_weight"` // Brake power
+	IsHot       bool       `json:"is_hot"`       // True = Active (Pulling), False = Dead (Towed)
+}
+
+const (
+	// hotBrakeShare is the brake weight of a working locomotive relative to its weight (simplified)
+	hotBrakeShare = 0.8
+	// maxLocomotiveMass is far above any main line locomotive; a heavier one was entered in kg
+	maxLocomotiveMass = 300 * units.Tonne
+)
+
+// NewLocomotive builds a locomotive of the composition. A hot locomotive brakes
+// with about 80 % of its weight; a dead one is hauled and its brakes are not counted.
+func NewLocomotive(id string, number int, weight units.Mass, hot bool) (Locomotive, error) {
+	l := Locomotive{ID: strings.TrimSpace(id), Number: number, Weight: weight, IsHot: hot}
+	if hot {
+		l.BrakeWeight = units.Tonnes(weight.Tonnes() * hotBrakeShare)
+	}
+	if err := l.Validate(); err != nil {
+		return Locomotive{}, err
+	}
+	return l, nil
+}
+
+// Validate reports every impossible value of the locomotive at once.
+func (l Locomotive) Validate() error {
+	var problems []string
+	if l.Number <= 0 {
+		problems = append(problems, "number must be positive")
+	}
+	if l.Weight <= 0 {
+		problems = append(problems, "weight must be positive")
+	} else if l.Weight > maxLocomotiveMass {
+		problems = append(problems, fmt.Sprintf("weight %v cannot be right, check it is in tonnes", l.Weight))
+	}
+	if l.BrakeWeight < 0 {
+		problems = append(problems, "brake weight cannot be negative")
+	} else if !l.IsHot && l.BrakeWeight > 0 {
+		problems = append(problems, fmt.Sprintf("a dead locomotive is hauled without brake weight, %v given", l.BrakeWeight))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("locomotive #%d: %s", l.Number, strings.Join(problems, "; "))
+	}
+	return nil
 }
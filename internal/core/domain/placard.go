@@ -0,0 +1,7 @@
+package domain
+
+// PlacardItem is one plate, placard or mark a dangerous wagon must carry.
+type PlacardItem struct {
+	Key  string // Stable identifier, stored when the examiner confirms the item
+	Text string
+}
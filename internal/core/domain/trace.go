@@ -0,0 +1,37 @@
+package domain
+
+import "railguard/internal/core/units"
+
+// SpeedRule is the cell of the speed table a max speed was read from.
+type SpeedRule struct {
+	Row           int         `json:"row"`            // 1-based row of the speed table, 0 when no row applies
+	Band          string      `json:"band"`           // Slope band of the row, e.g. "slope <= 20 permil"
+	MinPercentage int         `json:"min_percentage"` // Brake percentage the row asks for
+	MaxSpeed      units.Speed `json:"max_speed"`      // 0 when no row applies
+}
+
+// TraceVehicle is what one vehicle contributed to the calculation, and why.
+type TraceVehicle struct {
+	Position    int          `json:"position"` // 1-based, locomotives first
+	Label       string       `json:"label"`    // e.g. "wagon #123456"
+	Weight      units.Mass   `json:"weight"`
+	BrakeWeight units.Mass   `json:"brake_weight"`
+	Length      units.Length `json:"length"`
+	Axles       int          `json:"axles"`
+	Reason      string       `json:"reason"` // e.g. "loaded, air brake isolated"
+}
+
+// CalculationTrace is the step-by-step account of a calculation result, for
+// examiners to check how each number was reached.
+type CalculationTrace struct {
+	Slope           units.Gradient `json:"slope"`
+	Vehicles        []TraceVehicle `json:"vehicles"`
+	TotalWeight     units.Mass     `json:"total_weight"`
+	TotalBrake      units.Mass     `json:"total_brake"`
+	TotalLength     units.Length   `json:"total_length"`
+	AxleCount       int            `json:"axle_count"`
+	RawPercentage   float64        `json:"raw_percentage"` // Before rounding down
+	BrakePercentage int            `json:"brake_percentage"`
+	Rule            SpeedRule      `json:"rule"`
+	Steps           []string       `json:"steps"` // Every further check, in the order it was made
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "railguard/internal/core/units"
+
+// TractionResult is whether the locomotives can start and haul the train
+// up the ruling (steepest rising) gradient.
+type TractionResult struct {
+	RulingGradient     units.Gradient `json:"ruling_gradient"`     // Rising gradient the check was made for
+	TrailingLoad       units.Mass     `json:"trailing_load"`       // Behind the hot locomotives, dead ones included
+	StartingEffort     float64        `json:"starting_effort"`     // kN the hot locomotives give at standstill
+	StartingResistance float64        `json:"starting_resistance"` // kN to start the train on the ruling gradient
+	CanStart           bool           `json:"can_start"`
+	BalancingSpeed     units.Speed    `json:"balancing_speed"` // Where effort equals resistance on the ruling gradient
+	Warnings           []string       `json:"warnings,omitempty"`
+}
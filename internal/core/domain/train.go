@@ -1,21 +1,41 @@
 package domain
 
+import "railguard/internal/core/units"
+
 // Train represents the assembled train consisting of multiple wagons.
 type Train struct {
 	Locomotives []Locomotive    `json:"locomotives"` // List of locomotives (usually 1, but can be more for double-heading)
 	Wagons      []SelectedWagon `json:"wagons"`
-	TotalWeight float64         `json:"total_weight"` // Sum of all wagons' weight
-	TotalBrake  float64         `json:"total_brake"`  // Sum of all wagons' brake weight
-	TotalLength float64         `json:"total_length"` // Sum of all wagons' length
+	TotalWeight units.Mass      `json:"total_weight"` // Sum of all wagons' weight
+	TotalBrake  units.Mass      `json:"total_brake"`  // Sum of all wagons' brake weight
+	TotalLength units.Length    `json:"total_length"` // Sum of all wagons' length
 	AxleCount   int             `json:"axle_count"`   // Total number of axles
 }
 
 // CalculationResult holds the final output of the brake calculation.
 type CalculationResult struct {
-	BrakePercentage int    `json:"brake_percentage"` // Calculated brake percentage
-	MaxSpeed        int    `json:"max_speed"`        // Max allowed speed based on rules
-	IsSafe          bool   `json:"is_safe"`          // True if the train is allowed to depart
-	Message         string `json:"message"`          // Error or success message
+	BrakePercentage int         `json:"brake_percentage"` // Calculated brake percentage
+	MaxSpeed        units.Speed `json:"max_speed"`        // Max allowed speed based on rules
+	IsSafe          bool        `json:"is_safe"`          // True if the train is allowed to depart
+	Message         string      `json:"message"`          // Error or success message
+	RuleVersion     string      `json:"rule_version"`     // Rule set the result was computed with
+
+	Braking  *BrakingCurve  `json:"braking,omitempty"`  // Stopping distances over the speed range
+	Profile  string         `json:"profile,omitempty"`  // Gradient profile the result was computed over
+	Sections []RouteSection `json:"sections,omitempty"` // Section speeds along the profile
+
+	Traction *TractionResult `json:"traction,omitempty"` // Nil when the train has no locomotive
+	Couplers *CouplerCheck   `json:"couplers,omitempty"` // Nil when the train has no locomotive
+
+	ConsistWarnings []ConsistWarning `json:"consist_warnings,omitempty"` // Advisory, does not affect IsSafe
+
+	Trace *CalculationTrace `json:"trace,omitempty"` // How the result was reached
+}
+
+// ValidationResult is the outcome of the dangerous goods check of a composition.
+type ValidationResult struct {
+	Passed   bool     `json:"passed"`
+	Findings []string `json:"findings"` // Every conflict found, in train order
 }
 
 type DangerRule struct {
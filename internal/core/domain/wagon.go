@@ -1,5 +1,13 @@
 package domain
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"railguard/internal/core/units"
+	"strings"
+)
+
 type Wagon struct {
 	ID     int
 	Number int    // شماره واگن
@@ -7,34 +15,34 @@ type Wagon struct {
 	Axles  int    // تعداد محور
 
 	// --- Weights ---
-	WeightEmpty       float64 // وزن واگن خالی
-	WeightLoaded      float64 // وزن واگن با بار
-	MaxCapacity       float64 // ظرفیت بارگیری
-	LoadVolume        float64 // حجم بارگیری
-	BrakeWeightEmpty  float64 // وزن ترمز بی بار
-	BrakeWeightLoaded float64 // وزن ترمز با بار
+	WeightEmpty       units.Mass // وزن واگن خالی
+	WeightLoaded      units.Mass // وزن واگن با بار
+	MaxCapacity       units.Mass // ظرفیت بارگیری
+	LoadVolume        float64    // حجم بارگیری
+	BrakeWeightEmpty  units.Mass // وزن ترمز بی بار
+	BrakeWeightLoaded units.Mass // وزن ترمز با بار
 
 	// --- Dimensions ---
-	Length             float64 // طول واگن
-	LoadLength         float64 // طول بارگیری
-	LoadWidth          float64 // عرض بارگیری
-	FloorHeight        float64 // ارتفاع از ریل تا کف
-	InternalHeight     float64 // ارتفاع از کف به بالا
-	BogiePivotDistance float64 // فاصله مرکز دو بوژی
-	WheelDiameter      float64 // قطر چرخ
+	Length             units.Length // طول واگن
+	LoadLength         units.Length // طول بارگیری
+	LoadWidth          units.Length // عرض بارگیری
+	FloorHeight        units.Length // ارتفاع از ریل تا کف
+	InternalHeight     units.Length // ارتفاع از کف به بالا
+	BogiePivotDistance units.Length // فاصله مرکز دو بوژی
+	WheelDiameter      units.Length // قطر چرخ
 
 	// --- Technical Details ---
-	RIVCode           string  // حرف RIV
-	Manufacturer      string  // کشور سازنده
-	Year              string  // سال ورود
-	BogieType         string  // نوع بوژی
-	BearingType       string  // نوع جعبه یاتاقان
-	SpringType        string  // نوع فنر
-	HandBrakeType     string  // نوع ترمز دستی
-	HandBrakeWeight   float64 // وزن ترمز دستی
-	ControlValveType  string  // نوع سوپاپ سه قلو (KE1CSL...)
-	BrakeCylinderType string  // نوع خودکار ترمز (Cylinder)
-	CouplingType      string  // نوع قلاب
+	RIVCode           string     // حرف RIV
+	Manufacturer      string     // کشور سازنده
+	Year              string     // سال ورود
+	BogieType         string     // نوع بوژی
+	BearingType       string     // نوع جعبه یاتاقان
+	SpringType        string     // نوع فنر
+	HandBrakeType     string     // نوع ترمز دستی
+	HandBrakeWeight   units.Mass // وزن ترمز دستی
+	ControlValveType  string     // نوع سوپاپ سه قلو (KE1CSL...)
+	BrakeCylinderType string     // نوع خودکار ترمز (Cylinder)
+	CouplingType      string     // نوع قلاب
 }
 
 // SelectedWagon represents a wagon added to the train composition with specific user inputs (Dynamic Data).
@@ -42,14 +50,170 @@ type SelectedWagon struct {
 	WagonSpec Wagon // Embeds the static data
 
 	// User Inputs
-	IsMainBrakeHealthy   bool   // Status of the main air brake
-	IsHandBrakeHealthy   bool   // Status of the hand brake
-	IsBrakeHandleHealthy bool   // Status of the brake handle/valve
-	IsLoaded             bool   // True if loaded, False if empty
-	HasDangerousGoods    bool   // True if carrying dangerous goods
-	DangerousGoodsCode   string // The code of dangerous goods (e.g., "2a", "3b")
+	IsMainBrakeHealthy   bool             // Status of the main air brake
+	IsHandBrakeHealthy   bool             // Status of the hand brake
+	IsBrakeHandleHealthy bool             // Status of the brake handle/valve
+	IsLoaded             bool             // True if loaded, False if empty
+	HasDangerousGoods    bool             // True if carrying dangerous goods
+	DangerousGoodsCode   string           // The code of dangerous goods (e.g., "2a", "3b"); summarises Cargo when it is set
+	Cargo                []DangerousCargo // Every dangerous goods entry on board (compartments, mixed loads)
+	PlacardsConfirmed    []string         // Keys of the placard items the examiner found fitted
 
 	// Computed Values for Calculation
-	EffectiveWeight      float64 // Final weight based on Load Status
-	EffectiveBrakeWeight float64 // Final brake weight based on Brake Health & Load
+	EffectiveWeight      units.Mass // Final weight based on Load Status
+	EffectiveBrakeWeight units.Mass // Final brake weight based on Brake Health & Load
+}
+
+// WagonState is what the examiner records about a wagon joining the train.
+type WagonState struct {
+	IsLoaded             bool
+	IsMainBrakeHealthy   bool
+	IsHandBrakeHealthy   bool
+	IsBrakeHandleHealthy bool
+	Cargo                []DangerousCargo // Dangerous goods on board, none for an ordinary load
+	PlacardsConfirmed    []string
+}
+
+// NewSelectedWagon puts a wagon of the catalogue into the composition. The
+// effective weights are derived from the specification and the state.
+func NewSelectedWagon(spec Wagon, state WagonState) (SelectedWagon, error) {
+	w := SelectedWagon{
+		WagonSpec:            spec,
+		IsMainBrakeHealthy:   state.IsMainBrakeHealthy,
+		IsHandBrakeHealthy:   state.IsHandBrakeHealthy,
+		IsBrakeHandleHealthy: state.IsBrakeHandleHealthy,
+		IsLoaded:             state.IsLoaded,
+		PlacardsConfirmed:    state.PlacardsConfirmed,
+	}
+	w.SetCargo(state.Cargo)
+	w.EffectiveWeight, w.EffectiveBrakeWeight = w.effectiveWeights()
+	if err := w.Validate(); err != nil {
+		return SelectedWagon{}, err
+	}
+	return w, nil
+}
+
+// State returns the inputs the wagon was built from, for editing it.
+func (w SelectedWagon) State() WagonState {
+	return WagonState{
+		IsLoaded:             w.IsLoaded,
+		IsMainBrakeHealthy:   w.IsMainBrakeHealthy,
+		IsHandBrakeHealthy:   w.IsHandBrakeHealthy,
+		IsBrakeHandleHealthy: w.IsBrakeHandleHealthy,
+		Cargo:                w.DangerousCargo(),
+		PlacardsConfirmed:    w.PlacardsConfirmed,
+	}
+}
+
+// RatedBrakeWeight is the brake weight of the wagon in its load state with a working air brake.
+func (w SelectedWagon) RatedBrakeWeight() units.Mass {
+	if w.IsLoaded {
+		return w.WagonSpec.BrakeWeightLoaded
+	}
+	return w.WagonSpec.BrakeWeightEmpty
+}
+
+// effectiveWeights are the weight and brake weight the wagon counts with. An
+// isolated air brake contributes nothing.
+func (w SelectedWagon) effectiveWeights() (weight, brake units.Mass) {
+	weight = w.WagonSpec.WeightEmpty
+	if w.IsLoaded {
+		weight = w.WagonSpec.WeightLoaded
+	}
+	if w.IsMainBrakeHealthy {
+		brake = w.RatedBrakeWeight()
+	}
+	return weight, brake
+}
+
+// Validate reports every contradiction in the wagon's state at once.
+func (w SelectedWagon) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if w.HasDangerousGoods {
+		if !w.IsLoaded {
+			add("an empty wagon cannot carry dangerous goods")
+		}
+		for i, c := range w.DangerousCargo() {
+			if strings.TrimSpace(c.SegregationCode()) == "" {
+				add("dangerous goods entry %d has no class code", i+1)
+			}
+		}
+	} else if len(w.Cargo) > 0 || w.DangerousGoodsCode != "" {
+		add("dangerous goods %q are recorded but the wagon is not marked as carrying any", w.DangerousGoodsCode)
+	}
+
+	state := "empty"
+	if w.IsLoaded {
+		state = "loaded"
+	}
+	weight, brake := w.effectiveWeights()
+	if w.EffectiveWeight != weight {
+		add("weight %v does not match the %s weight %v", w.EffectiveWeight, state, weight)
+	}
+	if w.EffectiveBrakeWeight != brake {
+		if !w.IsMainBrakeHealthy {
+			add("brake weight %v counted with the air brake isolated", w.EffectiveBrakeWeight)
+		} else {
+			add("brake weight %v does not match the %s brake weight %v", w.EffectiveBrakeWeight, state, brake)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("wagon #%d: %s", w.WagonSpec.Number, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ClearStaleGoods cleans wagons read from history or a license. Wagon forms
+// before domain validation saved the selected dangerous goods code even with
+// "Dangerous Goods" unticked; such a code never counted and is dropped here, so
+// only newly built wagons have to pass the strict check of Validate.
+func ClearStaleGoods(wagons []SelectedWagon) {
+	for i := range wagons {
+		if !wagons[i].HasDangerousGoods {
+			wagons[i].DangerousGoodsCode = ""
+			wagons[i].Cargo = nil
+		}
+	}
+}
+
+// CheckVehicles validates every locomotive and wagon of a composition, for
+// compositions read back from history or a license. All problems are reported at once.
+func CheckVehicles(locos []Locomotive, wagons []SelectedWagon) error {
+	var problems []string
+	for _, l := range locos {
+		if err := l.Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	for i, w := range wagons {
+		if err := w.Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("position %d, %v", i+1, err))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// legacyWheelDiameter tells a wheel diameter saved in mm, before Wagon held it
+// as a Length, from one in metres.
+const legacyWheelDiameter = 10 * units.Metre
+
+// UnmarshalJSON reads wagons saved in history, licenses and the catalogue audit
+// trail when WheelDiameter was still a number of millimetres.
+func (w *Wagon) UnmarshalJSON(data []byte) error {
+	type plain Wagon
+	if err := json.Unmarshal(data, (*plain)(w)); err != nil {
+		return err
+	}
+	if w.WheelDiameter > legacyWheelDiameter {
+		w.WheelDiameter = units.Millimetres(float64(w.WheelDiameter))
+	}
+	return nil
 }
@@ -0,0 +1,179 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"railguard/internal/core/uic"
+	"railguard/internal/core/units"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxWagonRangeSize caps how many numbers one catalogue entry may cover
+const MaxWagonRangeSize = 10000
+
+// Plausible limits of a wagon specification. Values outside them are usually
+// entered in the wrong unit (kg for t, mm for m).
+const (
+	maxWagonMass     = 200 * units.Tonne
+	maxWagonLength   = 50 * units.Metre
+	minWheelDiameter = 600 * units.Millimetre
+	maxWheelDiameter = 1300 * units.Millimetre
+)
+
+// WagonRange is a block of consecutive wagon numbers sharing one specification.
+// The catalogue stores every number separately; ranges are how it is edited.
+// UIC numbers are keyed without their check digit, see uic.CatalogueKey.
+type WagonRange struct {
+	From    int
+	To      int
+	Spec    Wagon // Number and ID are ignored
+	Retired bool  // Retired wagons can no longer be added to a composition
+}
+
+func (r WagonRange) Size() int {
+	return r.To - r.From + 1
+}
+
+func (r WagonRange) Contains(number int) bool {
+	return number >= r.From && number <= r.To
+}
+
+// Validate checks the range and the specification for values that cannot be right.
+// All problems are reported at once.
+func (r WagonRange) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	national := uic.IsNationalKey(r.From) && uic.IsNationalKey(r.To)
+	international := uic.IsUICKey(r.From) && uic.IsUICKey(r.To)
+	numbersOK := false
+	if !national && !international {
+		add("both ends must be 6-digit national or both 12-digit UIC numbers")
+	} else if international && r.From/1000 != r.To/1000 {
+		add("a UIC range may only vary in the serial digits")
+	} else if r.From > r.To {
+		add("range start %d is after range end %d", r.From, r.To)
+	} else if r.Size() > MaxWagonRangeSize {
+		add("a range may cover at most %d wagons", MaxWagonRangeSize)
+	} else {
+		numbersOK = true
+	}
+
+	w := r.Spec
+	if strings.TrimSpace(w.Type) == "" {
+		add("wagon type is required")
+	}
+	if w.Axles <= 0 {
+		add("axle count must be positive")
+	}
+	if w.WeightEmpty <= 0 {
+		add("empty weight must be positive")
+	}
+	if w.WeightLoaded < w.WeightEmpty {
+		add("loaded weight (%v) is below empty weight (%v)", w.WeightLoaded, w.WeightEmpty)
+	}
+	if w.BrakeWeightEmpty <= 0 || w.BrakeWeightLoaded <= 0 {
+		add("brake weights must be positive")
+	}
+	if w.Length <= 0 {
+		add("length must be positive")
+	}
+	problems = append(problems, w.implausible()...)
+
+	// The numbers themselves encode axles and type, which the spec must agree with
+	if numbersOK && w.Axles > 0 {
+		problems = append(problems, r.axleProblems()...)
+		problems = append(problems, r.letterProblems()...)
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// CheckPlausible reports values no wagon can have, which are usually read in
+// the wrong unit (kg for t, mm for m). Missing (zero) values are not checked.
+func (w Wagon) CheckPlausible() error {
+	if problems := w.implausible(); len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (w Wagon) implausible() []string {
+	var problems []string
+	for _, m := range []units.Mass{w.WeightEmpty, w.WeightLoaded, w.BrakeWeightEmpty, w.BrakeWeightLoaded, w.MaxCapacity} {
+		if m > maxWagonMass {
+			problems = append(problems, fmt.Sprintf("weights above %v cannot be right, check they are in tonnes", maxWagonMass))
+			break
+		}
+	}
+	if w.Length > maxWagonLength {
+		problems = append(problems, fmt.Sprintf("length %v cannot be right, check it is in metres", w.Length))
+	}
+	if w.WheelDiameter != 0 && (w.WheelDiameter < minWheelDiameter || w.WheelDiameter > maxWheelDiameter) {
+		problems = append(problems, fmt.Sprintf("wheel diameter %.0f mm is outside %.0f-%.0f mm",
+			w.WheelDiameter.Millimetres(), minWheelDiameter.Millimetres(), maxWheelDiameter.Millimetres()))
+	}
+	return problems
+}
+
+// axleProblems compares the axle count with what both range ends decode to
+func (r WagonRange) axleProblems() []string {
+	var problems []string
+	keys := []int{r.From}
+	if r.To != r.From {
+		keys = append(keys, r.To)
+	}
+	for _, key := range keys {
+		if uic.IsNationalKey(key) {
+			if axles := uic.DecodeNationalKey(key).Axles; axles > 0 && axles != r.Spec.Axles {
+				problems = append(problems, fmt.Sprintf("wagon #%d decodes to %d axles, but the spec has %d", key, axles, r.Spec.Axles))
+			}
+			continue
+		}
+		n, err := uic.Parse(uic.FormatKey(key))
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if min, max, ok := n.AxleRange(); ok && (r.Spec.Axles < min || r.Spec.Axles > max) {
+			problems = append(problems, fmt.Sprintf("wagon %s is coded for %d to %d axles, but the spec has %d", n, min, max, r.Spec.Axles))
+		}
+	}
+	return problems
+}
+
+// letterProblems checks the RIV letter code against axles, type and both range ends
+func (r WagonRange) letterProblems() []string {
+	if r.Spec.RIVCode == "" {
+		return nil
+	}
+	problems := uic.CheckLetters(r.Spec.RIVCode, r.Spec.Axles, r.From)
+	if r.To != r.From {
+		for _, p := range uic.CheckLetters(r.Spec.RIVCode, r.Spec.Axles, r.To) {
+			if !slices.Contains(problems, p) {
+				problems = append(problems, p)
+			}
+		}
+	}
+	if p := uic.CheckLetterType(r.Spec.RIVCode, r.Spec.Type); p != "" {
+		problems = append(problems, p)
+	}
+	return problems
+}
+
+// CatalogueChange is an entry of the wagon catalogue audit trail.
+type CatalogueChange struct {
+	ID        int
+	ChangedAt time.Time
+	ChangedBy string
+	Action    string      // "add", "edit", "retire" or "reactivate"
+	Before    *WagonRange // nil when the range was added
+	After     *WagonRange
+}
@@ -0,0 +1,11 @@
+package ports
+
+import "railguard/internal/core/domain"
+
+// DangerousGoodsRepository looks up the dangerous goods list by UN number.
+type DangerousGoodsRepository interface {
+	// GetByUNNumber accepts the number with or without the "UN" prefix.
+	GetByUNNumber(un string) (*domain.DangerousGood, error)
+	// SearchGoods matches UN numbers and shipping names.
+	SearchGoods(query string) ([]domain.DangerousGood, error)
+}
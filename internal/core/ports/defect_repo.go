@@ -0,0 +1,17 @@
+package ports
+
+import "railguard/internal/core/domain"
+
+// DefectRepository keeps the register of defective wagons.
+type DefectRepository interface {
+	// ReportDefects opens a defect for every component, or refreshes the one already open.
+	ReportDefects(wagonNumber int, components []domain.DefectComponent, trainNumber string) error
+	// AttachTrain records the train on the open defects of the wagons that were
+	// reported before its number was known.
+	AttachTrain(wagonNumbers []int, trainNumber string) error
+	// OpenDefects returns the defects of a wagon that no workshop has closed yet.
+	OpenDefects(wagonNumber int) ([]domain.Defect, error)
+	// ListDefects returns the register, newest first. openOnly hides closed defects.
+	ListDefects(openOnly bool) ([]domain.Defect, error)
+	CloseDefect(id int, note string) error
+}
@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"railguard/internal/core/domain"
+	"time"
+)
+
+// LicenseRepository archives every brake license that was issued.
+type LicenseRepository interface {
+	// NextSequence returns the sequence number for the next license of a train on the given day.
+	NextSequence(trainNumber string, day time.Time) (int, error)
+	SaveLicense(rec domain.LicenseRecord) (int, error)
+	// SearchLicenses returns matching licenses, newest first.
+	SearchLicenses(filter domain.LicenseFilter) ([]domain.LicenseRecord, error)
+}
@@ -1,10 +1,20 @@
 package ports
 
-import "railguard/internal/core/domain"
+import (
+	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
+)
 
 // RuleRepository defines the interface for fetching brake and safety rules.
 type RuleRepository interface {
-	GetMaxSpeed(slope int, brakePercentage int) (int, error)
+	GetMaxSpeed(slope units.Gradient, brakePercentage int) (units.Speed, error)
+	// GetSpeedRule returns the speed table cell GetMaxSpeed reads its answer from.
+	GetSpeedRule(slope units.Gradient, brakePercentage int) (domain.SpeedRule, error)
+	// GetMinBrakePercentage is the lowest brake percentage allowing speed on slope.
+	// ok is false when no brake percentage allows that speed.
+	GetMinBrakePercentage(slope units.Gradient, speed units.Speed) (percentage int, ok bool, err error)
 	// New method to fetch all danger rules
 	GetAllDangerRules() ([]domain.DangerRule, error)
+	// GetRuleVersion identifies the rule set in use, so archived results can be traced back to it.
+	GetRuleVersion() (string, error)
 }
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"math"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
+)
+
+const (
+	gravity = 9.81 // m/s²
+
+	// Mean deceleration of a train braked at 100 %, cast-iron blocks on dry rail
+	decelerationPer100Percent = 0.85 // m/s²
+	// Wheel-rail adhesion caps the deceleration whatever the brake weight
+	maxAdhesionDeceleration = 1.5 // m/s²
+
+	// The brake command runs down the brake pipe at roughly this speed
+	brakeSignalSpeed = 250.0 // m/s
+
+	curveSpeedStep = 10 * units.KilometrePerHour  // Between points of the braking curve
+	curveTopSpeed  = 120 * units.KilometrePerHour // Used when the rules give no maximum speed
+)
+
+// cylinderFillTime is how long the brake cylinders take to reach full pressure
+// (UIC 540: 18-30 s in G, 3-5 s in P).
+func cylinderFillTime(regime domain.BrakeRegime) float64 {
+	if regime == domain.RegimePassenger {
+		return 4
+	}
+	return 25
+}
+
+// BrakingCurve computes the stopping distances of the train from walking pace up to
+// topSpeed on a falling gradient. The brakes build up linearly over
+// the cylinder fill time after the command reaches the last wagon, which is counted
+// as a dead time of the signal run plus half the fill time.
+func (s *BrakeCalculatorService) BrakingCurve(train *domain.Train, gradient units.Gradient, regime domain.BrakeRegime, topSpeed units.Speed) domain.BrakingCurve {
+	if regime == "" {
+		regime = domain.RegimeGoods
+	}
+	curve := domain.BrakingCurve{
+		Regime:      regime,
+		Gradient:    gradient,
+		BuildUpTime: train.TotalLength.Metres()/brakeSignalSpeed + cylinderFillTime(regime)/2,
+	}
+	if train.TotalWeight <= 0 {
+		return curve
+	}
+
+	braking := train.TotalBrake.Tonnes() / train.TotalWeight.Tonnes() * decelerationPer100Percent
+	braking = math.Min(braking, maxAdhesionDeceleration)
+	slope := gravity * gradient.Ratio() // Pulls the train downhill, m/s²
+	curve.Deceleration = braking - slope
+	curve.CanStop = curve.Deceleration > 0
+
+	if topSpeed <= 0 {
+		topSpeed = curveTopSpeed
+	}
+	if !curve.CanStop {
+		return curve
+	}
+	curve.Points = append(curve.Points, domain.BrakingPoint{})
+	for v := curveSpeedStep; ; v += curveSpeedStep {
+		v = min(v, topSpeed)
+		curve.Points = append(curve.Points, stoppingPoint(v, curve.BuildUpTime, slope, curve.Deceleration))
+		if v == topSpeed {
+			break
+		}
+	}
+	return curve
+}
+
+// stoppingPoint runs the train unbraked through the dead time, then brakes it to a stop.
+func stoppingPoint(speed units.Speed, deadTime, slope, deceleration float64) domain.BrakingPoint {
+	v := speed.MetresPerSecond()
+	if slope < 0 && v+slope*deadTime <= 0 {
+		// A rising gradient stops the train before the brakes apply
+		return domain.BrakingPoint{Speed: speed, Distance: units.Metres(v * v / (-2 * slope)), Time: v / -slope}
+	}
+	dead := v*deadTime + slope*deadTime*deadTime/2
+	v += slope * deadTime
+	return domain.BrakingPoint{
+		Speed:    speed,
+		Distance: units.Metres(dead + v*v/(2*deceleration)),
+		Time:     deadTime + v/deceleration,
+	}
+}
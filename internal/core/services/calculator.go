@@ -1,9 +1,18 @@
 package services
 
 import (
+	"fmt"
 	"math"
 	"railguard/internal/core/domain"
 	"railguard/internal/core/ports"
+	"railguard/internal/core/units"
+	"strings"
+)
+
+// Locomotives are counted with a typical main line length and axle count
+const (
+	locoLength = 20 * units.Metre
+	locoAxles  = 6
 )
 
 // BrakeCalculatorService handles the core logic for train brake calculations.
@@ -18,7 +27,18 @@ func NewBrakeCalculatorService(repo ports.RuleRepository) *BrakeCalculatorServic
 
 // CalculateTrainParameters computes the total weight, brake weight, and validation.
 // FIX: Input type changed to []domain.SelectedWagon
-func (s *BrakeCalculatorService) CalculateTrainParameters(locos []domain.Locomotive, wagons []domain.SelectedWagon, slope int) (*domain.CalculationResult, *domain.Train, error) {
+// A single slope is the falling gradient the train brakes on, it tells nothing
+// about climbing; without a route profile the locomotives must start the train on the level.
+func (s *BrakeCalculatorService) CalculateTrainParameters(locos []domain.Locomotive, wagons []domain.SelectedWagon, slope units.Gradient) (*domain.CalculationResult, *domain.Train, error) {
+	return s.calculate(locos, wagons, slope, 0)
+}
+
+// calculate brakes the train for a falling slope and checks its traction up a
+// rising gradient, which differ when a route profile is used.
+func (s *BrakeCalculatorService) calculate(locos []domain.Locomotive, wagons []domain.SelectedWagon, slope, rising units.Gradient) (*domain.CalculationResult, *domain.Train, error) {
+	if err := domain.CheckVehicles(locos, wagons); err != nil {
+		return nil, nil, err
+	}
 
 	train := &domain.Train{
 		Locomotives: locos,
@@ -28,12 +48,24 @@ func (s *BrakeCalculatorService) CalculateTrainParameters(locos []domain.Locomot
 		TotalLength: 0,
 		AxleCount:   0,
 	}
+	trace := &domain.CalculationTrace{Slope: slope}
+
 	// 1. Calculate Locomotives
 	for _, loco := range locos {
 		train.TotalWeight += loco.Weight
 		train.TotalBrake += loco.BrakeWeight
-		train.TotalLength += 20 // Approx length if not specified, or add Length to struct
-		train.AxleCount += 6    // Usually 6 axles for main line locos
+		train.TotalLength += locoLength // Approx length if not specified, or add Length to struct
+		train.AxleCount += locoAxles    // Usually 6 axles for main line locos
+
+		reason := "hot locomotive"
+		if !loco.IsHot {
+			reason = "dead locomotive, hauled"
+		}
+		trace.Vehicles = append(trace.Vehicles, domain.TraceVehicle{
+			Position: len(trace.Vehicles) + 1, Label: fmt.Sprintf("locomotive #%d", loco.Number),
+			Weight: loco.Weight, BrakeWeight: loco.BrakeWeight, Length: locoLength, Axles: locoAxles,
+			Reason: reason + ", 20 m / 6 axles assumed",
+		})
 	}
 
 	// 2. Calculate Wagons (Existing logic)
@@ -42,21 +74,49 @@ func (s *BrakeCalculatorService) CalculateTrainParameters(locos []domain.Locomot
 		train.TotalBrake += w.EffectiveBrakeWeight
 		train.TotalLength += w.WagonSpec.Length
 		train.AxleCount += w.WagonSpec.Axles
+
+		trace.Vehicles = append(trace.Vehicles, domain.TraceVehicle{
+			Position: len(trace.Vehicles) + 1, Label: fmt.Sprintf("wagon #%d", w.WagonSpec.Number),
+			Weight: w.EffectiveWeight, BrakeWeight: w.EffectiveBrakeWeight,
+			Length: w.WagonSpec.Length, Axles: w.WagonSpec.Axles,
+			Reason: wagonTraceReason(w),
+		})
 	}
+	trace.TotalWeight, trace.TotalBrake = train.TotalWeight, train.TotalBrake
+	trace.TotalLength, trace.AxleCount = train.TotalLength, train.AxleCount
 
 	if train.TotalWeight == 0 {
+		trace.Steps = append(trace.Steps, "Train weight is zero, nothing to calculate.")
 		return &domain.CalculationResult{
 			IsSafe:  false,
 			Message: "Train weight is zero.",
+			Trace:   trace,
 		}, train, nil
 	}
 
-	// 2. Calculate Brake Percentage formula: (TotalBrake / TotalWeight) * 100
-	rawPercentage := (train.TotalBrake / train.TotalWeight) * 100
-	brakePercentage := int(math.Floor(rawPercentage)) // Round down to be safe
+	// 2. Calculate Brake Percentage
+	brakePercentage := trainBrakePercentage(train)
+	trace.RawPercentage = train.TotalBrake.Tonnes() / train.TotalWeight.Tonnes() * 100
+	trace.BrakePercentage = brakePercentage
+	trace.Steps = append(trace.Steps, fmt.Sprintf("Brake percentage = %.2f t / %.2f t x 100 = %.4f %%, rounded down to %d %%",
+		train.TotalBrake.Tonnes(), train.TotalWeight.Tonnes(), trace.RawPercentage, brakePercentage))
 
 	// 3. Get Max Speed from Rules (Database)
-	maxSpeed, err := s.ruleRepo.GetMaxSpeed(slope, brakePercentage)
+	rule, err := s.ruleRepo.GetSpeedRule(slope, brakePercentage)
+	if err != nil {
+		return nil, nil, err
+	}
+	maxSpeed := rule.MaxSpeed
+	trace.Rule = rule
+	if rule.Row > 0 {
+		trace.Steps = append(trace.Steps, fmt.Sprintf("Speed table row %d (%s, from %d %%): %d %% on %g permil allows %v",
+			rule.Row, rule.Band, rule.MinPercentage, brakePercentage, slope.Permil(), maxSpeed))
+	} else {
+		trace.Steps = append(trace.Steps, fmt.Sprintf("Speed table (%s): %d %% is below the %d %% minimum, no speed allowed",
+			rule.Band, brakePercentage, rule.MinPercentage))
+	}
+
+	ruleVersion, err := s.ruleRepo.GetRuleVersion()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -65,15 +125,73 @@ func (s *BrakeCalculatorService) CalculateTrainParameters(locos []domain.Locomot
 	result := &domain.CalculationResult{
 		BrakePercentage: brakePercentage,
 		MaxSpeed:        maxSpeed,
+		RuleVersion:     ruleVersion,
+		Trace:           trace,
 	}
 
-	if maxSpeed > 0 {
-		result.IsSafe = true
-		result.Message = "Train is safe to depart."
-	} else {
-		result.IsSafe = false
-		result.Message = "Brake percentage is insufficient for this slope."
+	// Every reason the train may not depart, all of them are reported
+	var failures []string
+	if maxSpeed <= 0 {
+		failures = append(failures, "Brake percentage is insufficient for this slope.")
+	}
+
+	// 5. Dangerous wagons may only run with every plate and label confirmed
+	if findings := PlacardFindings(wagons); len(findings) > 0 {
+		failure := "Placard checklist incomplete: " + strings.Join(findings, "; ") + "."
+		failures = append(failures, failure)
+		trace.Steps = append(trace.Steps, failure)
+	}
+
+	// Advisory only, a risky order does not stop the train
+	result.ConsistWarnings = CheckConsistOrder(wagons)
+	for _, w := range result.ConsistWarnings {
+		trace.Steps = append(trace.Steps, "Consist order (advisory): "+w.Text)
+	}
+
+	// 6. The locomotives must be able to start the train
+	if len(locos) > 0 {
+		result.Traction = CheckTraction(train, rising)
+		trace.Steps = append(trace.Steps, fmt.Sprintf("Traction on %.1f permil: %.0f kN starting effort against %.0f kN starting resistance, balancing speed %v",
+			rising.Permil(), result.Traction.StartingEffort, result.Traction.StartingResistance, result.Traction.BalancingSpeed))
+		if !result.Traction.CanStart {
+			failures = append(failures, fmt.Sprintf("Locomotives cannot start the train on %.1f permil (%.0f kN available, %.0f kN needed).",
+				rising.Permil(), result.Traction.StartingEffort, result.Traction.StartingResistance))
+		}
+
+		// 7. Heavy trains can pull their couplings apart
+		result.Couplers = CheckCouplers(train, rising, result.Traction)
+		if len(result.Couplers.Loads) > 0 {
+			trace.Steps = append(trace.Steps, fmt.Sprintf("Couplers: %.0f kN at the first trailing coupling, limit %.0f kN (%s)",
+				result.Couplers.MaxForce, result.Couplers.Limit, result.Couplers.WeakestType))
+		}
+		if risk := result.Couplers.AtRisk; len(risk) > 0 {
+			failures = append(failures, fmt.Sprintf("Coupler overload: up to %.0f kN against the %.0f kN limit of %s couplings, %d coupling(s) at risk up to %s.",
+				result.Couplers.MaxForce, result.Couplers.Limit, result.Couplers.WeakestType, len(risk), risk[len(risk)-1].Behind))
+		}
 	}
 
+	result.IsSafe = len(failures) == 0
+	result.Message = "Train is safe to depart."
+	if !result.IsSafe {
+		result.Message = strings.Join(failures, " ")
+	}
+	trace.Steps = append(trace.Steps, "Verdict: "+result.Message)
 	return result, train, nil
 }
+
+// wagonTraceReason explains the weight and brake weight a wagon contributes
+func wagonTraceReason(w domain.SelectedWagon) string {
+	reason := "empty: empty weights"
+	if w.IsLoaded {
+		reason = "loaded: loaded weights"
+	}
+	if !w.IsMainBrakeHealthy {
+		reason += ", air brake isolated (0 t)"
+	}
+	return reason
+}
+
+// trainBrakePercentage is (TotalBrake / TotalWeight) * 100, rounded down to be safe
+func trainBrakePercentage(train *domain.Train) int {
+	return int(math.Floor(train.TotalBrake.Tonnes() / train.TotalWeight.Tonnes() * 100))
+}
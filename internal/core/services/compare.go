@@ -0,0 +1,137 @@
+package services
+
+import (
+	"fmt"
+	"railguard/internal/core/domain"
+)
+
+// CompareCompositions recalculates both compositions and reports wagons added,
+// removed, reordered or changed, along with the change in brake performance.
+func (s *BrakeCalculatorService) CompareCompositions(before, after domain.CompositionSnapshot) (*domain.CompositionDiff, error) {
+	oldRes, oldTrain, err := s.CalculateTrainParameters(before.Locos, before.Wagons, before.Slope)
+	if err != nil {
+		return nil, err
+	}
+	newRes, newTrain, err := s.CalculateTrainParameters(after.Locos, after.Wagons, after.Slope)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &domain.CompositionDiff{
+		OldResult:            oldRes,
+		NewResult:            newRes,
+		WeightDelta:          newTrain.TotalWeight - oldTrain.TotalWeight,
+		BrakeWeightDelta:     newTrain.TotalBrake - oldTrain.TotalBrake,
+		BrakePercentageDelta: newRes.BrakePercentage - oldRes.BrakePercentage,
+		MaxSpeedDelta:        newRes.MaxSpeed - oldRes.MaxSpeed,
+	}
+
+	oldPos := wagonPositions(before.Wagons)
+	newPos := wagonPositions(after.Wagons)
+
+	for i, w := range before.Wagons {
+		if _, ok := newPos[w.WagonSpec.Number]; !ok {
+			diff.Removed = append(diff.Removed, domain.WagonChange{Number: w.WagonSpec.Number, OldPos: i + 1})
+		}
+	}
+	for i, w := range after.Wagons {
+		if _, ok := oldPos[w.WagonSpec.Number]; !ok {
+			diff.Added = append(diff.Added, domain.WagonChange{Number: w.WagonSpec.Number, NewPos: i + 1})
+		}
+	}
+
+	// Wagons present on both sides, in the new order
+	var common []int
+	for _, w := range after.Wagons {
+		if _, ok := oldPos[w.WagonSpec.Number]; ok {
+			common = append(common, w.WagonSpec.Number)
+		}
+	}
+
+	// The longest subsequence kept in the same relative order did not move,
+	// every other common wagon was reordered.
+	stayed := longestOrderedSubset(common, oldPos)
+	for _, n := range common {
+		change := domain.WagonChange{Number: n, OldPos: oldPos[n] + 1, NewPos: newPos[n] + 1}
+		if !stayed[n] {
+			diff.Moved = append(diff.Moved, change)
+		}
+		change.Details = wagonStateChanges(before.Wagons[oldPos[n]], after.Wagons[newPos[n]])
+		if len(change.Details) > 0 {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+
+	return diff, nil
+}
+
+func wagonPositions(wagons []domain.SelectedWagon) map[int]int {
+	pos := make(map[int]int, len(wagons))
+	for i, w := range wagons {
+		pos[w.WagonSpec.Number] = i
+	}
+	return pos
+}
+
+// longestOrderedSubset returns the largest set of numbers (in new order) whose
+// old positions are increasing, i.e. the longest increasing subsequence.
+func longestOrderedSubset(numbers []int, oldPos map[int]int) map[int]bool {
+	n := len(numbers)
+	length := make([]int, n)
+	prev := make([]int, n)
+	best := -1
+	for i := 0; i < n; i++ {
+		length[i], prev[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if oldPos[numbers[j]] < oldPos[numbers[i]] && length[j]+1 > length[i] {
+				length[i], prev[i] = length[j]+1, j
+			}
+		}
+		if best < 0 || length[i] > length[best] {
+			best = i
+		}
+	}
+
+	stayed := make(map[int]bool)
+	for i := best; i >= 0; i = prev[i] {
+		stayed[numbers[i]] = true
+	}
+	return stayed
+}
+
+func wagonStateChanges(a, b domain.SelectedWagon) []string {
+	var details []string
+	flag := func(name string, before, after bool, yes, no string) {
+		if before == after {
+			return
+		}
+		from, to := no, yes
+		if before {
+			from, to = yes, no
+		}
+		details = append(details, fmt.Sprintf("%s: %s → %s", name, from, to))
+	}
+
+	flag("Load", a.IsLoaded, b.IsLoaded, "loaded", "empty")
+	flag("Air brake", a.IsMainBrakeHealthy, b.IsMainBrakeHealthy, "healthy", "isolated")
+	flag("Hand brake", a.IsHandBrakeHealthy, b.IsHandBrakeHealthy, "healthy", "defective")
+	flag("Brake handle", a.IsBrakeHandleHealthy, b.IsBrakeHandleHealthy, "healthy", "defective")
+
+	if a.HasDangerousGoods != b.HasDangerousGoods || goodsLabel(a) != goodsLabel(b) {
+		details = append(details, fmt.Sprintf("Dangerous goods: %s → %s", goodsLabel(a), goodsLabel(b)))
+	}
+	if a.EffectiveWeight != b.EffectiveWeight {
+		details = append(details, fmt.Sprintf("Weight: %v → %v", a.EffectiveWeight, b.EffectiveWeight))
+	}
+	if a.EffectiveBrakeWeight != b.EffectiveBrakeWeight {
+		details = append(details, fmt.Sprintf("Brake weight: %v → %v", a.EffectiveBrakeWeight, b.EffectiveBrakeWeight))
+	}
+	return details
+}
+
+func goodsLabel(w domain.SelectedWagon) string {
+	if !w.HasDangerousGoods {
+		return "none"
+	}
+	return w.GoodsLabel()
+}
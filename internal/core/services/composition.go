@@ -0,0 +1,380 @@
+package services
+
+import (
+	"fmt"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
+)
+
+// CompositionChangeKind tells listeners what happened to the working composition.
+type CompositionChangeKind string
+
+const (
+	ChangeEdited CompositionChangeKind = "edited" // A command changed the vehicles
+	ChangeLoaded CompositionChangeKind = "loaded" // The vehicles were replaced, e.g. from history
+	ChangeUndone CompositionChangeKind = "undone"
+	ChangeRedone CompositionChangeKind = "redone"
+	ChangeSlope  CompositionChangeKind = "slope" // Only the slope changed; it is not part of undo
+)
+
+// CompositionEvent is sent to every listener after a change.
+type CompositionEvent struct {
+	Kind   CompositionChangeKind
+	Action string // The command concerned, e.g. "remove wagon #312045"
+}
+
+// maxUndoSteps bounds the undo history of a working session
+const maxUndoSteps = 100
+
+// compositionStep is one entry of the undo or redo history: the vehicles
+// before (undo) or after (redo) a command.
+type compositionStep struct {
+	action string
+	locos  []domain.Locomotive
+	wagons []domain.SelectedWagon
+	origin *compositionOrigin // Only set around a load, which changes where the train came from
+}
+
+// compositionOrigin ties the working train to its history entry and trip.
+type compositionOrigin struct {
+	historyID int
+	trip      domain.TripInfo
+}
+
+// CompositionService owns the train being built. Every change goes through a
+// command that validates the vehicles, records an undo step and notifies the
+// listeners, so the screens only render the state and send commands.
+// It is not safe for concurrent use.
+type CompositionService struct {
+	locos  []domain.Locomotive
+	wagons []domain.SelectedWagon
+	slope  units.Gradient
+	origin compositionOrigin
+
+	undo, redo []compositionStep
+	listeners  []func(CompositionEvent)
+}
+
+// NewCompositionService starts an empty composition on the given slope.
+func NewCompositionService(slope units.Gradient) *CompositionService {
+	return &CompositionService{slope: slope}
+}
+
+// OnChange registers a listener called after every change.
+func (s *CompositionService) OnChange(listener func(CompositionEvent)) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// Locomotives returns a copy of the locomotives in train order.
+func (s *CompositionService) Locomotives() []domain.Locomotive {
+	return append([]domain.Locomotive(nil), s.locos...)
+}
+
+// Wagons returns a copy of the wagons in train order.
+func (s *CompositionService) Wagons() []domain.SelectedWagon {
+	return append([]domain.SelectedWagon(nil), s.wagons...)
+}
+
+// Slope is the falling gradient the train is calculated for.
+func (s *CompositionService) Slope() units.Gradient {
+	return s.slope
+}
+
+// Snapshot returns the composition as saved to history or compared against it.
+func (s *CompositionService) Snapshot() domain.CompositionSnapshot {
+	return domain.CompositionSnapshot{
+		Locos:     s.Locomotives(),
+		Wagons:    s.Wagons(),
+		Slope:     s.slope,
+		HistoryID: s.origin.historyID,
+		Trip:      s.origin.trip,
+	}
+}
+
+// HistoryID is the history entry the composition is saved as, 0 if it has not
+// been saved yet. Saving again updates that entry.
+func (s *CompositionService) HistoryID() int {
+	return s.origin.historyID
+}
+
+// Trip is the trip the composition runs as.
+func (s *CompositionService) Trip() domain.TripInfo {
+	return s.origin.trip
+}
+
+// SetHistoryID records the history entry the composition was saved as. Like
+// the trip it is not undoable on its own; only undoing a load restores it.
+func (s *CompositionService) SetHistoryID(id int) {
+	s.origin.historyID = id
+}
+
+// SetTrip records the trip the composition runs as.
+func (s *CompositionService) SetTrip(trip domain.TripInfo) {
+	s.origin.trip = trip
+}
+
+// ForgetHistory drops every reference to a deleted history entry, including
+// the ones an undo or redo of a load would bring back.
+func (s *CompositionService) ForgetHistory(id int) {
+	if s.origin.historyID == id {
+		s.origin.historyID = 0
+	}
+	for _, history := range [][]compositionStep{s.undo, s.redo} {
+		for i := range history {
+			if o := history[i].origin; o != nil && o.historyID == id {
+				history[i].origin = &compositionOrigin{trip: o.trip}
+			}
+		}
+	}
+}
+
+// IsEmpty tells whether the composition has no vehicles at all.
+func (s *CompositionService) IsEmpty() bool {
+	return len(s.locos) == 0 && len(s.wagons) == 0
+}
+
+// SetSlope changes the slope. Typing in the slope entry would flood the undo
+// history, so slope changes are not undoable; an unchanged slope sends no event.
+func (s *CompositionService) SetSlope(slope units.Gradient) {
+	if slope == s.slope {
+		return
+	}
+	s.slope = slope
+	s.notify(CompositionEvent{Kind: ChangeSlope, Action: fmt.Sprintf("set slope %g permil", slope.Permil())})
+}
+
+// AddLocomotive couples a locomotive behind the ones already in the train.
+func (s *CompositionService) AddLocomotive(l domain.Locomotive) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+	locos := append(s.Locomotives(), l)
+	s.apply(fmt.Sprintf("add locomotive #%d", l.Number), locos, s.wagons)
+	return nil
+}
+
+// RemoveLocomotive takes the locomotive at index out of the train.
+func (s *CompositionService) RemoveLocomotive(index int) error {
+	if err := s.checkLocoIndex(index); err != nil {
+		return err
+	}
+	action := fmt.Sprintf("remove locomotive #%d", s.locos[index].Number)
+	locos := append(s.Locomotives()[:index], s.locos[index+1:]...)
+	s.apply(action, locos, s.wagons)
+	return nil
+}
+
+// ToggleLocomotiveHot switches a locomotive between working and hauled dead,
+// which brings its brake weight in or takes it out.
+func (s *CompositionService) ToggleLocomotiveHot(index int) error {
+	if err := s.checkLocoIndex(index); err != nil {
+		return err
+	}
+	old := s.locos[index]
+	l, err := domain.NewLocomotive(old.ID, old.Number, old.Weight, !old.IsHot)
+	if err != nil {
+		return err
+	}
+	state := "dead"
+	if l.IsHot {
+		state = "hot"
+	}
+	locos := s.Locomotives()
+	locos[index] = l
+	s.apply(fmt.Sprintf("set locomotive #%d %s", l.Number, state), locos, s.wagons)
+	return nil
+}
+
+// AddWagon couples a wagon at the end of the train.
+func (s *CompositionService) AddWagon(w domain.SelectedWagon) error {
+	return s.InsertWagon(len(s.wagons), w)
+}
+
+// InsertWagon puts a wagon at index, pushing the following wagons back.
+func (s *CompositionService) InsertWagon(index int, w domain.SelectedWagon) error {
+	if index < 0 || index > len(s.wagons) {
+		return fmt.Errorf("wagon position %d is outside the train of %d wagons", index+1, len(s.wagons))
+	}
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	wagons := make([]domain.SelectedWagon, 0, len(s.wagons)+1)
+	wagons = append(append(append(wagons, s.wagons[:index]...), w), s.wagons[index:]...)
+	s.apply(fmt.Sprintf("add wagon #%d", w.WagonSpec.Number), s.locos, wagons)
+	return nil
+}
+
+// ReplaceWagon stores the edited state of the wagon at index.
+func (s *CompositionService) ReplaceWagon(index int, w domain.SelectedWagon) error {
+	if err := s.checkWagonIndex(index); err != nil {
+		return err
+	}
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	wagons := s.Wagons()
+	wagons[index] = w
+	s.apply(fmt.Sprintf("edit wagon #%d", w.WagonSpec.Number), s.locos, wagons)
+	return nil
+}
+
+// MoveWagon takes the wagon at from out of the train and puts it back at to.
+func (s *CompositionService) MoveWagon(from, to int) error {
+	if err := s.checkWagonIndex(from); err != nil {
+		return err
+	}
+	if err := s.checkWagonIndex(to); err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+	w := s.wagons[from]
+	wagons := append(s.Wagons()[:from], s.wagons[from+1:]...)
+	wagons = append(wagons[:to], append([]domain.SelectedWagon{w}, wagons[to:]...)...)
+	s.apply(fmt.Sprintf("move wagon #%d to position %d", w.WagonSpec.Number, to+1), s.locos, wagons)
+	return nil
+}
+
+// RemoveWagon takes the wagon at index out of the train.
+func (s *CompositionService) RemoveWagon(index int) error {
+	if err := s.checkWagonIndex(index); err != nil {
+		return err
+	}
+	action := fmt.Sprintf("remove wagon #%d", s.wagons[index].WagonSpec.Number)
+	wagons := append(s.Wagons()[:index], s.wagons[index+1:]...)
+	s.apply(action, s.locos, wagons)
+	return nil
+}
+
+// ToggleWagonLoaded switches a wagon between empty and loaded. A wagon with
+// dangerous goods on board cannot be made empty.
+func (s *CompositionService) ToggleWagonLoaded(index int) error {
+	return s.toggleWagon(index, "load state", func(st *domain.WagonState) { st.IsLoaded = !st.IsLoaded })
+}
+
+// ToggleWagonMainBrake cuts the air brake of a wagon in or out.
+func (s *CompositionService) ToggleWagonMainBrake(index int) error {
+	return s.toggleWagon(index, "air brake", func(st *domain.WagonState) { st.IsMainBrakeHealthy = !st.IsMainBrakeHealthy })
+}
+
+func (s *CompositionService) toggleWagon(index int, what string, change func(*domain.WagonState)) error {
+	if err := s.checkWagonIndex(index); err != nil {
+		return err
+	}
+	old := s.wagons[index]
+	state := old.State()
+	change(&state)
+	w, err := domain.NewSelectedWagon(old.WagonSpec, state)
+	if err != nil {
+		return err
+	}
+	wagons := s.Wagons()
+	wagons[index] = w
+	s.apply(fmt.Sprintf("toggle %s of wagon #%d", what, w.WagonSpec.Number), s.locos, wagons)
+	return nil
+}
+
+// Load replaces the whole composition, its slope, history entry and trip, e.g.
+// with a train from history or a license. Loading can be undone like any other
+// command; undoing it also brings back the history entry and trip before, so
+// saving afterwards does not overwrite the loaded entry.
+func (s *CompositionService) Load(snapshot domain.CompositionSnapshot) error {
+	if err := domain.CheckVehicles(snapshot.Locos, snapshot.Wagons); err != nil {
+		return err
+	}
+	s.record(&s.undo, "load train", true)
+	s.redo = nil
+	s.locos = append([]domain.Locomotive(nil), snapshot.Locos...)
+	s.wagons = append([]domain.SelectedWagon(nil), snapshot.Wagons...)
+	s.slope = snapshot.Slope
+	s.origin = compositionOrigin{historyID: snapshot.HistoryID, trip: snapshot.Trip}
+	s.notify(CompositionEvent{Kind: ChangeLoaded, Action: "load train"})
+	return nil
+}
+
+// CanUndo tells whether there is a command to undo and names it.
+func (s *CompositionService) CanUndo() (string, bool) {
+	if len(s.undo) == 0 {
+		return "", false
+	}
+	return s.undo[len(s.undo)-1].action, true
+}
+
+// CanRedo tells whether there is an undone command to redo and names it.
+func (s *CompositionService) CanRedo() (string, bool) {
+	if len(s.redo) == 0 {
+		return "", false
+	}
+	return s.redo[len(s.redo)-1].action, true
+}
+
+// Undo reverts the last command. It reports false when there is nothing to undo.
+func (s *CompositionService) Undo() bool {
+	return s.step(&s.undo, &s.redo, ChangeUndone)
+}
+
+// Redo repeats the last undone command. It reports false when there is nothing to redo.
+func (s *CompositionService) Redo() bool {
+	return s.step(&s.redo, &s.undo, ChangeRedone)
+}
+
+// step restores the newest entry of from and records the current state on to.
+func (s *CompositionService) step(from, to *[]compositionStep, kind CompositionChangeKind) bool {
+	if len(*from) == 0 {
+		return false
+	}
+	last := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	s.record(to, last.action, last.origin != nil)
+	s.locos, s.wagons = last.locos, last.wagons
+	if last.origin != nil {
+		s.origin = *last.origin
+	}
+	s.notify(CompositionEvent{Kind: kind, Action: last.action})
+	return true
+}
+
+// apply makes the new vehicles current after recording the old ones for undo.
+// A new command drops whatever had been undone.
+func (s *CompositionService) apply(action string, locos []domain.Locomotive, wagons []domain.SelectedWagon) {
+	s.record(&s.undo, action, false)
+	s.redo = nil
+	s.locos, s.wagons = locos, wagons
+	s.notify(CompositionEvent{Kind: ChangeEdited, Action: action})
+}
+
+// record pushes the current vehicles, and with withOrigin their history entry
+// and trip, onto a history. The slices are never changed in place once
+// current, so they are shared rather than copied.
+func (s *CompositionService) record(history *[]compositionStep, action string, withOrigin bool) {
+	step := compositionStep{action: action, locos: s.locos, wagons: s.wagons}
+	if withOrigin {
+		origin := s.origin
+		step.origin = &origin
+	}
+	*history = append(*history, step)
+	if len(*history) > maxUndoSteps {
+		*history = (*history)[len(*history)-maxUndoSteps:]
+	}
+}
+
+func (s *CompositionService) notify(e CompositionEvent) {
+	for _, l := range s.listeners {
+		l(e)
+	}
+}
+
+func (s *CompositionService) checkLocoIndex(index int) error {
+	if index < 0 || index >= len(s.locos) {
+		return fmt.Errorf("no locomotive at position %d", index+1)
+	}
+	return nil
+}
+
+func (s *CompositionService) checkWagonIndex(index int) error {
+	if index < 0 || index >= len(s.wagons) {
+		return fmt.Errorf("no wagon at position %d", index+1)
+	}
+	return nil
+}
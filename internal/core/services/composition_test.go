@@ -0,0 +1,250 @@
+package services
+
+import (
+	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testWagon(t *testing.T, number int, state domain.WagonState) domain.SelectedWagon {
+	t.Helper()
+	spec := domain.Wagon{
+		Number:            number,
+		Axles:             4,
+		WeightEmpty:       units.Tonnes(22),
+		WeightLoaded:      units.Tonnes(80),
+		BrakeWeightEmpty:  units.Tonnes(20),
+		BrakeWeightLoaded: units.Tonnes(58),
+	}
+	w, err := domain.NewSelectedWagon(spec, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func testLoco(t *testing.T, number int) domain.Locomotive {
+	t.Helper()
+	l, err := domain.NewLocomotive("GM", number, units.Tonnes(120), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+// newTestComposition starts a composition with the given wagons, all empty
+// with a working air brake, and forgets the undo steps of building it.
+func newTestComposition(t *testing.T, numbers ...int) *CompositionService {
+	t.Helper()
+	s := NewCompositionService(units.Permil(10))
+	for _, n := range numbers {
+		if err := s.AddWagon(testWagon(t, n, domain.WagonState{IsMainBrakeHealthy: true})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.undo = nil
+	return s
+}
+
+func wagonNumbers(s *CompositionService) []int {
+	var numbers []int
+	for _, w := range s.Wagons() {
+		numbers = append(numbers, w.WagonSpec.Number)
+	}
+	return numbers
+}
+
+func TestCompositionUndoRedo(t *testing.T) {
+	s := newTestComposition(t, 1, 2, 3)
+	var events []CompositionEvent
+	s.OnChange(func(e CompositionEvent) { events = append(events, e) })
+
+	if err := s.MoveWagon(0, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveWagon(0); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := wagonNumbers(s), []int{3, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wagons after edits = %v, want %v", got, want)
+	}
+
+	if action, ok := s.CanUndo(); !ok || action != "remove wagon #2" {
+		t.Fatalf("CanUndo = %q, %v", action, ok)
+	}
+	s.Undo()
+	if got, want := wagonNumbers(s), []int{2, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wagons after undo = %v, want %v", got, want)
+	}
+	s.Undo()
+	if got, want := wagonNumbers(s), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wagons after second undo = %v, want %v", got, want)
+	}
+	if s.Undo() {
+		t.Fatal("Undo with an empty history reported true")
+	}
+
+	s.Redo()
+	if got, want := wagonNumbers(s), []int{2, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wagons after redo = %v, want %v", got, want)
+	}
+
+	// A new command drops what was undone
+	if err := s.ToggleWagonLoaded(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.CanRedo(); ok {
+		t.Fatal("redo still offered after a new command")
+	}
+	if !s.Wagons()[0].IsLoaded {
+		t.Fatal("wagon not loaded after toggle")
+	}
+
+	kinds := make([]CompositionChangeKind, len(events))
+	for i, e := range events {
+		kinds[i] = e.Kind
+	}
+	want := []CompositionChangeKind{ChangeEdited, ChangeEdited, ChangeUndone, ChangeUndone, ChangeRedone, ChangeEdited}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("events = %v, want %v", kinds, want)
+	}
+}
+
+func TestCompositionUndoLimit(t *testing.T) {
+	s := newTestComposition(t, 1, 2)
+	for i := 0; i < maxUndoSteps+5; i++ {
+		if err := s.ToggleWagonMainBrake(0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	undone := 0
+	for s.Undo() {
+		undone++
+	}
+	if undone != maxUndoSteps {
+		t.Fatalf("undid %d steps, want %d", undone, maxUndoSteps)
+	}
+}
+
+func TestCompositionIndexChecks(t *testing.T) {
+	s := newTestComposition(t, 1, 2)
+	if err := s.AddLocomotive(testLoco(t, 1501)); err != nil {
+		t.Fatal(err)
+	}
+	s.undo = nil
+
+	tests := []struct {
+		name string
+		run  func() error
+		want string
+	}{
+		{"move to past the end", func() error { return s.MoveWagon(0, 5) }, "no wagon at position 6"},
+		{"move from before the start", func() error { return s.MoveWagon(-1, 0) }, "no wagon at position 0"},
+		{"remove missing wagon", func() error { return s.RemoveWagon(2) }, "no wagon at position 3"},
+		{"replace missing wagon", func() error { return s.ReplaceWagon(2, s.Wagons()[0]) }, "no wagon at position 3"},
+		{"toggle missing wagon", func() error { return s.ToggleWagonLoaded(7) }, "no wagon at position 8"},
+		{"insert past the end", func() error {
+			return s.InsertWagon(3, testWagon(t, 9, domain.WagonState{}))
+		}, "wagon position 4 is outside the train of 2 wagons"},
+		{"remove missing locomotive", func() error { return s.RemoveLocomotive(1) }, "no locomotive at position 2"},
+		{"toggle missing locomotive", func() error { return s.ToggleLocomotiveHot(-1) }, "no locomotive at position 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if got, want := wagonNumbers(s), []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("rejected commands changed the wagons to %v", got)
+	}
+	if _, ok := s.CanUndo(); ok {
+		t.Fatal("rejected commands recorded an undo step")
+	}
+}
+
+func TestCompositionRejectsInvalidWagons(t *testing.T) {
+	s := newTestComposition(t)
+	goods := testWagon(t, 7, domain.WagonState{
+		IsLoaded:           true,
+		IsMainBrakeHealthy: true,
+		Cargo:              []domain.DangerousCargo{{Code: "3"}},
+	})
+	if err := s.AddWagon(goods); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.ToggleWagonLoaded(0); err == nil || !strings.Contains(err.Error(), "an empty wagon cannot carry dangerous goods") {
+		t.Fatalf("emptying a wagon with dangerous goods: error = %v", err)
+	}
+	if !s.Wagons()[0].IsLoaded {
+		t.Fatal("rejected toggle emptied the wagon")
+	}
+
+	tampered := goods
+	tampered.EffectiveBrakeWeight = units.Tonnes(99)
+	if err := s.ReplaceWagon(0, tampered); err == nil {
+		t.Fatal("wagon with a wrong brake weight accepted")
+	}
+	if err := s.Load(domain.CompositionSnapshot{Wagons: []domain.SelectedWagon{tampered}}); err == nil {
+		t.Fatal("load of a wagon with a wrong brake weight accepted")
+	}
+}
+
+func TestCompositionUndoLoadRestoresOrigin(t *testing.T) {
+	s := newTestComposition(t, 1)
+	before := domain.TripInfo{TrainNumber: "401"}
+	s.SetTrip(before)
+	s.SetHistoryID(3)
+
+	loaded := domain.CompositionSnapshot{
+		Wagons:    []domain.SelectedWagon{testWagon(t, 2, domain.WagonState{IsMainBrakeHealthy: true})},
+		Slope:     units.Permil(25),
+		HistoryID: 8,
+		Trip:      domain.TripInfo{TrainNumber: "512"},
+	}
+	if err := s.Load(loaded); err != nil {
+		t.Fatal(err)
+	}
+	if s.HistoryID() != 8 || s.Trip() != loaded.Trip || s.Slope() != loaded.Slope {
+		t.Fatalf("after load: entry #%d, trip %q, slope %v", s.HistoryID(), s.Trip().TrainNumber, s.Slope())
+	}
+
+	// Edits after the load keep the entry, so saving updates it
+	if err := s.ToggleWagonLoaded(0); err != nil {
+		t.Fatal(err)
+	}
+	s.Undo()
+	if s.HistoryID() != 8 {
+		t.Fatalf("undoing an edit changed the entry to #%d", s.HistoryID())
+	}
+
+	// Undoing the load must not leave the previous train pointing at entry #8
+	s.Undo()
+	if got, want := wagonNumbers(s), []int{1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("wagons after undoing the load = %v, want %v", got, want)
+	}
+	if s.HistoryID() != 3 || s.Trip() != before {
+		t.Fatalf("after undoing the load: entry #%d, trip %q", s.HistoryID(), s.Trip().TrainNumber)
+	}
+	if snap := s.Snapshot(); snap.HistoryID != 3 || snap.Trip != before {
+		t.Fatalf("snapshot after undoing the load: entry #%d, trip %q", snap.HistoryID, snap.Trip.TrainNumber)
+	}
+
+	s.Redo()
+	if s.HistoryID() != 8 || s.Trip() != loaded.Trip {
+		t.Fatalf("after redoing the load: entry #%d, trip %q", s.HistoryID(), s.Trip().TrainNumber)
+	}
+
+	// A deleted entry is neither current nor brought back by undo
+	s.ForgetHistory(3)
+	s.Undo()
+	if s.HistoryID() != 0 || s.Trip() != before {
+		t.Fatalf("undo after deleting entry #3: entry #%d, trip %q", s.HistoryID(), s.Trip().TrainNumber)
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
+)
+
+// Thresholds of the consist-order check
+const (
+	lightWagonMass     = 35 * units.Tonne   // At most this is a light wagon, typically an empty one
+	lightBlockMin      = 5                  // Light wagons in a row that make a weak block
+	heavyBehindRatio   = 2.0                // Mass behind a light block, relative to the block, that is risky
+	heavyBehindMin     = 400 * units.Tonne  // and at least this much
+	massJumpMin        = 45 * units.Tonne   // Mass difference between neighbours that is a jump
+	massJumpRatio      = 2.5                // when the rear wagon is also this many times heavier
+	heavyTrainMass     = 1500 * units.Tonne // Wagon mass from which the rear of the train matters
+	emptyRearMinWagons = 3                  // Empty wagons at the rear that are flagged
+)
+
+// CheckConsistOrder scans the wagon order for load distributions that cause high
+// in-train forces when braking. Its warnings are advisory, separate from the
+// brake and dangerous goods verdicts.
+func CheckConsistOrder(wagons []domain.SelectedWagon) []domain.ConsistWarning {
+	var warnings []domain.ConsistWarning
+	mass := func(i int) units.Mass { return wagons[i].EffectiveWeight }
+	var total units.Mass
+	for i := range wagons {
+		total += mass(i)
+	}
+
+	// 1. Strings of light wagons pushed by heavy mass behind them
+	blockEnds := map[int]bool{}
+	for i := 0; i < len(wagons); {
+		if mass(i) > lightWagonMass {
+			i++
+			continue
+		}
+		start, block := i, units.Mass(0)
+		for ; i < len(wagons) && mass(i) <= lightWagonMass; i++ {
+			block += mass(i)
+		}
+		var behind units.Mass
+		for j := i; j < len(wagons); j++ {
+			behind += mass(j)
+		}
+		if i-start >= lightBlockMin && behind >= heavyBehindMin && behind >= heavyBehindRatio*block {
+			blockEnds[i-1] = true
+			warnings = append(warnings, domain.ConsistWarning{
+				Kind: domain.ConsistHeavyBehindLight, From: start + 1, To: i,
+				Text: fmt.Sprintf("Positions %d-%d: %d light wagons (%.0f t) with %.0f t behind them", start+1, i, i-start, block.Tonnes(), behind.Tonnes()),
+				Fix:  "Move the heavy wagons ahead of the light ones, or spread the light wagons between loaded wagons",
+			})
+		}
+	}
+
+	// 2. Big mass jumps between neighbours, heavier towards the rear
+	for i := 0; i+1 < len(wagons); i++ {
+		front, rear := mass(i), mass(i+1)
+		if blockEnds[i] || rear-front < massJumpMin || rear < massJumpRatio*front {
+			continue
+		}
+		warnings = append(warnings, domain.ConsistWarning{
+			Kind: domain.ConsistMassJump, From: i + 1, To: i + 2,
+			Text: fmt.Sprintf("Positions %d-%d: wagon #%d (%.0f t) directly behind wagon #%d (%.0f t)",
+				i+1, i+2, wagons[i+1].WagonSpec.Number, rear.Tonnes(), wagons[i].WagonSpec.Number, front.Tonnes()),
+			Fix: fmt.Sprintf("Place wagon #%d next to wagons of similar mass", wagons[i+1].WagonSpec.Number),
+		})
+	}
+
+	// 3. Empty wagons at the rear of a heavy train
+	if total >= heavyTrainMass {
+		n := 0
+		for i := len(wagons) - 1; i >= 0 && !wagons[i].IsLoaded; i-- {
+			n++
+		}
+		if n >= emptyRearMinWagons {
+			warnings = append(warnings, domain.ConsistWarning{
+				Kind: domain.ConsistEmptyRear, From: len(wagons) - n + 1, To: len(wagons),
+				Text: fmt.Sprintf("Positions %d-%d: %d empty wagons at the rear of a %.0f t train", len(wagons)-n+1, len(wagons), n, total.Tonnes()),
+				Fix:  "Move the empty wagons forward, behind the locomotive, or spread them between loaded wagons",
+			})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
+	"sort"
+	"strings"
+)
+
+// Permitted drawbar loads in kN
+const (
+	screwCouplingLimit    = 450  // UIC screw coupling and drawgear
+	automaticCouplerLimit = 1000 // Unicoupler / SA-3 automatic coupler
+)
+
+// couplingLimits maps the catalogue's coupling types to their permitted drawbar load
+var couplingLimits = map[string]float64{
+	"زنجیری":     screwCouplingLimit,
+	"screw":      screwCouplingLimit,
+	"یونی کوپلر": automaticCouplerLimit,
+	"unicoupler": automaticCouplerLimit,
+	"sa3":        automaticCouplerLimit,
+	"sa-3":       automaticCouplerLimit,
+	"automatic":  automaticCouplerLimit,
+}
+
+// couplingLimit returns the permitted drawbar load of a coupling type. Unknown
+// types get the screw coupling limit, which is the lowest.
+func couplingLimit(couplingType string) (float64, bool) {
+	limit, ok := couplingLimits[strings.ToLower(strings.TrimSpace(couplingType))]
+	if !ok {
+		return screwCouplingLimit, false
+	}
+	return limit, true
+}
+
+// CheckCouplers estimates the force on every coupling behind the hot locomotives
+// when the train starts on the rising gradient at full tractive effort. Each
+// coupling pulls the resistance of the mass behind it plus its share of the
+// acceleration, so the force falls off towards the rear.
+func CheckCouplers(train *domain.Train, rising units.Gradient, traction *domain.TractionResult) *domain.CouplerCheck {
+	check := &domain.CouplerCheck{Limit: math.Inf(1)}
+
+	type vehicle struct {
+		label, coupling string
+		position        int
+		mass            units.Mass
+	}
+	var vehicles []vehicle
+	lastHot := -1
+	for _, l := range train.Locomotives {
+		if l.IsHot {
+			lastHot = len(vehicles)
+		}
+		vehicles = append(vehicles, vehicle{label: fmt.Sprintf("locomotive #%d", l.Number), mass: l.Weight})
+	}
+	unknown := map[string]bool{}
+	for i, w := range train.Wagons {
+		vehicles = append(vehicles, vehicle{
+			label: fmt.Sprintf("wagon #%d", w.WagonSpec.Number), coupling: w.WagonSpec.CouplingType,
+			position: i + 1, mass: w.EffectiveWeight,
+		})
+		limit, known := couplingLimit(w.WagonSpec.CouplingType)
+		if !known {
+			unknown[w.WagonSpec.CouplingType] = true
+		}
+		if limit < check.Limit {
+			check.Limit, check.WeakestType = limit, w.WagonSpec.CouplingType
+		}
+	}
+	for t := range unknown {
+		check.UnknownTypes = append(check.UnknownTypes, t)
+	}
+	sort.Strings(check.UnknownTypes)
+	if lastHot < 0 || len(train.Wagons) == 0 || train.TotalWeight == 0 {
+		check.Limit = 0
+		return check
+	}
+
+	effort := traction.StartingEffort
+	perTon := (startingResistance + gravity*rising.Permil()) / 1000 // kN/t
+	accelerating := effort > train.TotalWeight.Tonnes()*perTon
+
+	behind := train.TotalWeight
+	for i := 0; i <= lastHot; i++ {
+		behind -= vehicles[i].mass
+	}
+	for i := lastHot + 1; i < len(vehicles); i++ {
+		force := math.Min(effort, behind.Tonnes()*perTon)
+		if accelerating {
+			force = effort * behind.Tonnes() / train.TotalWeight.Tonnes()
+		}
+		v := vehicles[i]
+		load := domain.CouplerLoad{
+			Position: v.position, Behind: v.label, CouplingType: weakerCoupling(vehicles[i-1].coupling, v.coupling),
+			TrailingMass: behind, Force: force,
+		}
+		check.Loads = append(check.Loads, load)
+		check.MaxForce = math.Max(check.MaxForce, force)
+		if force > check.Limit {
+			check.AtRisk = append(check.AtRisk, load)
+		}
+		behind -= v.mass
+	}
+	return check
+}
+
+// weakerCoupling returns the coupling type with the lower limit; locomotives have none
+func weakerCoupling(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	la, _ := couplingLimit(a)
+	lb, _ := couplingLimit(b)
+	if la <= lb {
+		return a
+	}
+	return b
+}
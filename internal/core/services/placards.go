@@ -0,0 +1,140 @@
+package services
+
+import (
+	"fmt"
+	"railguard/internal/core/domain"
+	"slices"
+	"strings"
+)
+
+// subsidiaryLabels maps the letters of a classification code after the main hazard
+// to the danger label they add, e.g. FT1 (class 3) also needs the 6.1 label.
+var subsidiaryLabels = map[rune]string{
+	'F': "3", 'T': "6.1", 'C': "8", 'O': "5.1", 'W': "4.3", 'S': "4.2",
+}
+
+// environmentallyHazardous lists the UN numbers that always need the environmentally hazardous substance mark
+var environmentallyHazardous = map[string]bool{"3077": true, "3082": true}
+
+// RequiredPlacards lists the orange plates, danger labels and marks a wagon must carry,
+// derived from the UN number of every entry, or from the bare class code when no UN
+// number is known. Labels shared by several entries are listed once.
+func RequiredPlacards(w domain.SelectedWagon) []domain.PlacardItem {
+	var items []domain.PlacardItem
+	add := func(item domain.PlacardItem) {
+		if !slices.ContainsFunc(items, func(i domain.PlacardItem) bool { return i.Key == item.Key }) {
+			items = append(items, item)
+		}
+	}
+	label := func(class string) {
+		add(domain.PlacardItem{Key: "label:" + class, Text: fmt.Sprintf("Class %s danger labels on both sides", class)})
+	}
+
+	for _, c := range w.DangerousCargo() {
+		g := c.Goods
+		if g == nil {
+			add(domain.PlacardItem{Key: "plate", Text: "Orange plates on both sides (hazard and UN number as on the shipping documents)"})
+			label(classFromCode(c.Code))
+			continue
+		}
+
+		if g.HazardNumber != "" {
+			add(domain.PlacardItem{
+				Key:  "plate:" + g.HazardNumber + "/" + g.UNNumber,
+				Text: fmt.Sprintf("Orange plates %s / %s on both sides", g.HazardNumber, g.UNNumber),
+			})
+		} else {
+			add(domain.PlacardItem{Key: "plate", Text: "Plain orange plates on both sides"})
+		}
+		for _, l := range dangerLabels(*g) {
+			label(l)
+		}
+		if environmentallyHazardous[g.UNNumber] {
+			add(domain.PlacardItem{Key: "mark:environment", Text: "Environmentally hazardous substance mark on both sides"})
+		}
+	}
+	return items
+}
+
+// dangerLabels returns the main label followed by the subsidiary labels
+func dangerLabels(g domain.DangerousGood) []string {
+	letters := strings.TrimLeft(g.ClassificationCode, "0123456789.")
+
+	switch g.Class {
+	case "1":
+		// The classification code starts with the division, e.g. 1.1D
+		if division := strings.TrimRight(g.ClassificationCode, "ABCDEFGHJKLNS"); division != "" {
+			return []string{division}
+		}
+		return []string{"1"}
+	case "2":
+		return gasLabels(letters)
+	}
+
+	labels := []string{g.Class}
+	for i, r := range letters {
+		if i == 0 {
+			continue // The first letter is the main hazard
+		}
+		if label, ok := subsidiaryLabels[r]; ok && !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// gasLabels picks the class 2 division: toxic before flammable before non-flammable
+func gasLabels(letters string) []string {
+	var labels []string
+	switch {
+	case strings.Contains(letters, "T"):
+		labels = append(labels, "2.3")
+		if strings.Contains(letters, "F") {
+			labels = append(labels, "2.1")
+		}
+	case strings.Contains(letters, "F"):
+		labels = append(labels, "2.1")
+	default:
+		labels = append(labels, "2.2")
+	}
+	if strings.Contains(letters, "O") {
+		labels = append(labels, "5.1")
+	}
+	if strings.Contains(letters, "C") {
+		labels = append(labels, "8")
+	}
+	return labels
+}
+
+// classFromCode turns a matrix code such as "4-1" or "2at" into a class
+func classFromCode(code string) string {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "?"
+	}
+	class := strings.TrimRight(strings.Fields(code)[0], "abcdefghijklmnopqrstuvwxyz")
+	return strings.ReplaceAll(class, "-", ".")
+}
+
+// PendingPlacards lists the placard items the examiner has not confirmed on a wagon
+func PendingPlacards(w domain.SelectedWagon) []domain.PlacardItem {
+	var pending []domain.PlacardItem
+	for _, item := range RequiredPlacards(w) {
+		if !slices.Contains(w.PlacardsConfirmed, item.Key) {
+			pending = append(pending, item)
+		}
+	}
+	return pending
+}
+
+// PlacardFindings describes every wagon whose placard checklist is incomplete
+func PlacardFindings(wagons []domain.SelectedWagon) []string {
+	var findings []string
+	for i, w := range wagons {
+		if pending := PendingPlacards(w); len(pending) > 0 {
+			findings = append(findings, fmt.Sprintf("Wagon #%d (position %d): %d placard item(s) not confirmed",
+				w.WagonSpec.Number, i+1, len(pending)))
+		}
+	}
+	return findings
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
+	"sort"
+)
+
+// RequiredBraking is the inverse of CalculateTrainParameters: for a line speed booked
+// on slope it returns the brake percentage the speed table asks for, the brake
+// weight the train is missing, and changes to the composition that would close the gap.
+func (s *BrakeCalculatorService) RequiredBraking(locos []domain.Locomotive, wagons []domain.SelectedWagon, slope units.Gradient, speed units.Speed) (*domain.BrakeRequirement, error) {
+	_, train, err := s.CalculateTrainParameters(locos, wagons, slope)
+	if err != nil {
+		return nil, err
+	}
+	req := &domain.BrakeRequirement{Slope: slope, Speed: speed}
+	req.RequiredPercentage, req.Achievable, err = s.ruleRepo.GetMinBrakePercentage(slope, speed)
+	if err != nil || !req.Achievable || train.TotalWeight == 0 {
+		return req, err
+	}
+	req.CurrentPercentage = trainBrakePercentage(train)
+	if req.Met() {
+		return req, nil
+	}
+
+	required := float64(req.RequiredPercentage) / 100
+	req.MissingBrakeWeight = units.Tonnes(required*train.TotalWeight.Tonnes()) - train.TotalBrake
+
+	percentage := func(brake, weight units.Mass) int {
+		return trainBrakePercentage(&domain.Train{TotalBrake: brake, TotalWeight: weight})
+	}
+	add := func(sug domain.BrakeSuggestion, brake, weight units.Mass) {
+		sug.Percentage = percentage(brake, weight)
+		sug.Closes = sug.Percentage >= req.RequiredPercentage
+		req.Suggestions = append(req.Suggestions, sug)
+	}
+
+	for i, w := range wagons {
+		// An isolated brake adds its whole rated brake weight once it works again
+		if rated := w.RatedBrakeWeight(); rated > w.EffectiveBrakeWeight {
+			gain := rated - w.EffectiveBrakeWeight
+			add(domain.BrakeSuggestion{
+				Kind: domain.SuggestCutInBrake, WagonNumber: w.WagonSpec.Number, Position: i + 1, BrakeWeight: gain,
+				Text: fmt.Sprintf("Repair or cut in the air brake of wagon #%d (position %d): +%.1f t brake weight", w.WagonSpec.Number, i+1, gain.Tonnes()),
+			}, train.TotalBrake+gain, train.TotalWeight)
+		}
+		// A wagon braked below the requirement drags the train down, leaving it behind helps
+		if w.EffectiveWeight > 0 && w.EffectiveBrakeWeight.Tonnes() < required*w.EffectiveWeight.Tonnes() && len(wagons) > 1 {
+			add(domain.BrakeSuggestion{
+				Kind: domain.SuggestDetach, WagonNumber: w.WagonSpec.Number, Position: i + 1,
+				Text: fmt.Sprintf("Detach wagon #%d (position %d, %.1f t, braked %.0f %%)", w.WagonSpec.Number, i+1, w.EffectiveWeight.Tonnes(), w.EffectiveBrakeWeight.Tonnes()/w.EffectiveWeight.Tonnes()*100),
+			}, train.TotalBrake-w.EffectiveBrakeWeight, train.TotalWeight-w.EffectiveWeight)
+		}
+	}
+
+	// Extra braked weight always closes the gap. It is counted over and above the
+	// required share of the weight of the vehicles that bring it.
+	extra := units.Tonnes(math.Ceil(req.MissingBrakeWeight.Tonnes()*10) / 10)
+	add(domain.BrakeSuggestion{
+		Kind: domain.SuggestAddBraked, BrakeWeight: extra,
+		Text: fmt.Sprintf("Add braked vehicles bringing %.1f t brake weight more than %d %% of their own weight", extra.Tonnes(), req.RequiredPercentage),
+	}, train.TotalBrake+extra, train.TotalWeight)
+
+	sort.SliceStable(req.Suggestions, func(i, j int) bool {
+		return req.Suggestions[i].Percentage > req.Suggestions[j].Percentage
+	})
+	return req, nil
+}
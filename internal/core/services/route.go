@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
+)
+
+// CalculateRoute runs the brake calculation over a gradient profile instead of a
+// single slope. Every section gets its governing gradient and max speed; the overall
+// result and braking curve are those of the steepest governing gradient of the route,
+// and traction is checked up its steepest rising gradient.
+func (s *BrakeCalculatorService) CalculateRoute(locos []domain.Locomotive, wagons []domain.SelectedWagon, profile domain.GradientProfile, regime domain.BrakeRegime) (*domain.CalculationResult, *domain.Train, error) {
+	if err := profile.Validate(); err != nil {
+		return nil, nil, err
+	}
+	res, train, err := s.CalculateTrainParameters(locos, wagons, 0)
+	if err != nil || train.TotalWeight == 0 {
+		return res, train, err
+	}
+
+	sections, err := s.RouteSections(train, profile, regime)
+	if err != nil {
+		return nil, nil, err
+	}
+	steepest := units.Gradient(math.Inf(-1))
+	for _, sec := range sections {
+		steepest = max(steepest, sec.Governing)
+	}
+
+	res, train, err = s.calculate(locos, wagons, GoverningSlope(steepest), profile.SteepestRising())
+	if err != nil {
+		return nil, nil, err
+	}
+	curve := s.BrakingCurve(train, steepest, regime, res.MaxSpeed)
+	res.Braking = &curve
+	res.Profile = profile.Name
+	res.Sections = sections
+	if res.Trace != nil {
+		step := fmt.Sprintf("Route profile %s: %d sections, steepest governing gradient %.1f permil taken as %g permil, steepest rise %.1f permil",
+			profile.Name, len(sections), steepest.Permil(), GoverningSlope(steepest).Permil(), profile.SteepestRising().Permil())
+		res.Trace.Steps = append([]string{step}, res.Trace.Steps...)
+	}
+	return res, train, nil
+}
+
+// RouteSections computes the governing gradient and max speed of every section of
+// the profile. A train braking anywhere in a section may run on past its end by the
+// stopping distance, so the steepest falling gradient over that stretch governs.
+// A steeper governing gradient lowers the speed and shortens the stretch, so the
+// search is repeated until the gradient no longer changes.
+func (s *BrakeCalculatorService) RouteSections(train *domain.Train, profile domain.GradientProfile, regime domain.BrakeRegime) ([]domain.RouteSection, error) {
+	percentage := trainBrakePercentage(train)
+	sections := make([]domain.RouteSection, 0, len(profile.Points)-1)
+	for i := 0; i+1 < len(profile.Points); i++ {
+		sec := domain.RouteSection{
+			From:      profile.Points[i].Chainage,
+			To:        profile.Points[i+1].Chainage,
+			Gradient:  profile.Points[i].Gradient,
+			Governing: profile.Points[i].Gradient,
+		}
+		// Every round either settles or moves to a steeper gradient of the profile
+		for range profile.Points {
+			speed, err := s.ruleRepo.GetMaxSpeed(GoverningSlope(sec.Governing), percentage)
+			if err != nil {
+				return nil, err
+			}
+			curve := s.BrakingCurve(train, sec.Governing, regime, speed)
+			distance, ok := curve.DistanceAt(speed)
+			if !ok {
+				speed, distance = 0, 0
+			}
+			sec.MaxSpeed, sec.StoppingDistance = speed, distance
+
+			governing := profile.SteepestFalling(sec.From, sec.To+distance)
+			if governing <= sec.Governing {
+				break
+			}
+			sec.Governing = governing
+		}
+		sections = append(sections, sec)
+	}
+	return sections, nil
+}
+
+// GoverningSlope rounds a gradient up to the whole permil of the speed rules.
+// Rising gradients count as level.
+func GoverningSlope(gradient units.Gradient) units.Gradient {
+	return units.Permil(math.Ceil(max(gradient.Permil(), 0)))
+}
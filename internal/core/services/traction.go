@@ -0,0 +1,157 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
+	"sort"
+	"strings"
+)
+
+// tractionModel is the tractive effort curve of a locomotive class: constant
+// starting effort up to the speed where the rail power takes over.
+type tractionModel struct {
+	name           string
+	startingEffort float64 // kN
+	railPower      float64 // kW at the rail
+	maxSpeed       units.Speed
+}
+
+// tractionModels are matched against the start of the locomotive model entered
+// on the dashboard (e.g. "GM-12" is a GM). Values are typical for the classes.
+var tractionModels = map[string]tractionModel{
+	"GM":      {name: "GM GT26CW", startingEffort: 355, railPower: 1800, maxSpeed: 120},
+	"ALSTOM":  {name: "Alstom AD43C", startingEffort: 400, railPower: 2600, maxSpeed: 120},
+	"AD43C":   {name: "Alstom AD43C", startingEffort: 400, railPower: 2600, maxSpeed: 120},
+	"SIEMENS": {name: "Siemens ER24PC", startingEffort: 270, railPower: 1950, maxSpeed: 160},
+	"ER24":    {name: "Siemens ER24PC", startingEffort: 270, railPower: 1950, maxSpeed: 160},
+}
+
+const (
+	adhesionCoefficient = 0.30 // Dry rail, used for locomotives of unknown class
+	unknownRailPower    = 1500 // kW, assumed for locomotives of unknown class
+	unknownMaxSpeed     = 100 * units.KilometrePerHour
+	minEffortSpeed      = 1 * units.KilometrePerHour // Avoids dividing the rail power by zero
+	balancingSpeedStep  = 0.5 * units.KilometrePerHour
+
+	// Metric Davis formula, R [N] = a·m + b·n + c·m·v + d·A·v²
+	davisPerTon       = 6.4  // a, N/t
+	davisPerAxle      = 130  // b, N per axle
+	davisPerTonPerKmh = 0.14 // c, N/(t·km/h)
+	aeroBase          = 0.046
+	aeroPerMetre      = 0.00043 // d grows with train length, about 0.0065 per 15 m wagon
+	frontalArea       = 10      // A, m²
+
+	startingResistance = 35 // N/t to break away from standstill
+)
+
+// lookupTractionModel finds the class of a locomotive, or derives one from its
+// adhesion weight. ok is false for the derived ones.
+func lookupTractionModel(loco domain.Locomotive) (tractionModel, bool) {
+	id := strings.ToUpper(strings.TrimSpace(loco.ID))
+	prefixes := make([]string, 0, len(tractionModels))
+	for p := range tractionModels {
+		prefixes = append(prefixes, p)
+	}
+	// Longest prefix first, so "AD43C" is not taken for a shorter match
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	for _, p := range prefixes {
+		if strings.HasPrefix(id, p) {
+			return tractionModels[p], true
+		}
+	}
+	return tractionModel{
+		name:           loco.ID,
+		startingEffort: loco.Weight.Tonnes() * gravity * adhesionCoefficient,
+		railPower:      unknownRailPower,
+		maxSpeed:       unknownMaxSpeed,
+	}, false
+}
+
+// effort is the tractive effort in kN at speed
+func (m tractionModel) effort(speed units.Speed) float64 {
+	if speed > m.maxSpeed {
+		return 0
+	}
+	return math.Min(m.startingEffort, m.railPower/max(speed, minEffortSpeed).MetresPerSecond())
+}
+
+// davisResistance is the running resistance of the train in kN at speed on level
+// track, from the metric Davis formula. The air drag grows with the length.
+func davisResistance(train *domain.Train, speed units.Speed) float64 {
+	m, v := train.TotalWeight.Tonnes(), speed.KmPerHour()
+	n := davisPerTon*m + davisPerAxle*float64(train.AxleCount) + davisPerTonPerKmh*m*v +
+		(aeroBase+aeroPerMetre*train.TotalLength.Metres())*frontalArea*v*v
+	return n / 1000
+}
+
+// gradeResistance is the force in kN needed to hold the train on a rising gradient
+func gradeResistance(train *domain.Train, rising units.Gradient) float64 {
+	return train.TotalWeight.Tonnes() * gravity * rising.Ratio()
+}
+
+// CheckTraction checks the hot locomotives can start the train on the ruling
+// (rising) gradient and finds the speed they can hold on it.
+func CheckTraction(train *domain.Train, rising units.Gradient) *domain.TractionResult {
+	res := &domain.TractionResult{RulingGradient: rising, TrailingLoad: train.TotalWeight}
+
+	var hot []tractionModel
+	var hotNums, deadNums []string
+	for _, loco := range train.Locomotives {
+		if !loco.IsHot {
+			deadNums = append(deadNums, fmt.Sprintf("#%d", loco.Number))
+			continue
+		}
+		model, known := lookupTractionModel(loco)
+		if !known {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("Locomotive #%d (%s): class unknown, tractive effort estimated from its weight", loco.Number, orUnknown(loco.ID)))
+		}
+		hot = append(hot, model)
+		hotNums = append(hotNums, fmt.Sprintf("#%d", loco.Number))
+		res.TrailingLoad -= loco.Weight
+	}
+	if len(hot) == 0 {
+		res.Warnings = append(res.Warnings, "No hot locomotive in the set")
+		return res
+	}
+	if len(deadNums) > 0 {
+		res.Warnings = append(res.Warnings, fmt.Sprintf("Hot (%s) and dead (%s) locomotives are mixed: dead units are hauled as trailing load and must be set for dead-in-train working",
+			strings.Join(hotNums, ", "), strings.Join(deadNums, ", ")))
+	}
+
+	effort := func(speed units.Speed) float64 {
+		total := 0.0
+		for _, m := range hot {
+			total += m.effort(speed)
+		}
+		return total
+	}
+	maxSpeed := hot[0].maxSpeed
+	for _, m := range hot[1:] {
+		maxSpeed = min(maxSpeed, m.maxSpeed) // The slowest unit limits the set
+	}
+
+	grade := gradeResistance(train, rising)
+	res.StartingEffort = effort(0)
+	res.StartingResistance = train.TotalWeight.Tonnes()*startingResistance/1000 + grade
+	res.CanStart = res.StartingEffort > res.StartingResistance
+	if !res.CanStart {
+		return res
+	}
+
+	for v := balancingSpeedStep; v <= maxSpeed; v += balancingSpeedStep {
+		if effort(v) < davisResistance(train, v)+grade {
+			break
+		}
+		res.BalancingSpeed = v
+	}
+	return res
+}
+
+func orUnknown(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "no model"
+	}
+	return s
+}
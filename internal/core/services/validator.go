@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"railguard/internal/core/domain"
 	"railguard/internal/core/ports"
+	"slices"
 )
 
 type SafetyValidatorService struct {
@@ -30,9 +31,40 @@ func NewSafetyValidatorService(repo ports.RuleRepository) (*SafetyValidatorServi
 }
 
 // ValidateComposition checks the train order against dangerous goods matrix
+// and reports the first conflict.
 func (v *SafetyValidatorService) ValidateComposition(wagons []domain.SelectedWagon) (bool, string) {
+	res := v.CheckComposition(wagons)
+	if !res.Passed {
+		return false, res.Findings[0]
+	}
+	return true, "All checks passed."
+}
+
+// CheckComposition checks the train order against dangerous goods matrix
+// and collects every conflict. Wagons with several entries are checked for
+// mixing inside the wagon, and the strictest pair decides the separation
+// from other wagons.
+func (v *SafetyValidatorService) CheckComposition(wagons []domain.SelectedWagon) domain.ValidationResult {
+	var findings []string
 
-	// Iterate through all wagons to find pairs of dangerous goods
+	// 1. Mixed loads: every pair inside one wagon must be allowed together
+	for _, w := range wagons {
+		cargo := w.DangerousCargo()
+		for a := 0; a < len(cargo); a++ {
+			for b := a + 1; b < len(cargo); b++ {
+				status := v.getRuleStatus(cargo[a].SegregationCode(), cargo[b].SegregationCode())
+				if status == noRule {
+					findings = append(findings, fmt.Sprintf("No matrix rule for %s and %s: check the mixed load of Wagon #%d by hand",
+						cargo[a].Label(), cargo[b].Label(), w.WagonSpec.Number))
+				} else if requiredDistance(status) > 0 {
+					findings = append(findings, fmt.Sprintf("Conflict: Wagon #%d carries %s and %s, which may not be loaded together",
+						w.WagonSpec.Number, cargo[a].Label(), cargo[b].Label()))
+				}
+			}
+		}
+	}
+
+	// 2. Iterate through all wagons to find pairs of dangerous goods
 	for i := 0; i < len(wagons); i++ {
 		// If wagon i has no dangerous goods, skip
 		if !wagons[i].HasDangerousGoods {
@@ -46,11 +78,8 @@ func (v *SafetyValidatorService) ValidateComposition(wagons []domain.SelectedWag
 				continue
 			}
 
-			// Both wagon i and j have dangerous goods. Check compatibility.
-			codeA := wagons[i].DangerousGoodsCode
-			codeB := wagons[j].DangerousGoodsCode
-
-			status := v.getRuleStatus(codeA, codeB)
+			// Both wagon i and j have dangerous goods. The strictest pair of entries decides.
+			status, codeA, codeB := v.strictestRule(wagons[i].DangerousCargo(), wagons[j].DangerousCargo())
 			distance := j - i // Difference in index (1 means adjacent)
 
 			// Logic based on your matrix definition
@@ -58,39 +87,95 @@ func (v *SafetyValidatorService) ValidateComposition(wagons []domain.SelectedWag
 			case "-":
 				// Cannot be adjacent
 				if distance == 1 {
-					return false, fmt.Sprintf("Conflict: Wagon #%d (%s) cannot be adjacent to Wagon #%d (%s)",
-						wagons[i].WagonSpec.Number, codeA, wagons[j].WagonSpec.Number, codeB)
+					findings = append(findings, fmt.Sprintf("Conflict: Wagon #%d (%s) cannot be adjacent to Wagon #%d (%s)",
+						wagons[i].WagonSpec.Number, codeA, wagons[j].WagonSpec.Number, codeB))
 				}
 			case "1":
 				// Needs 1 buffer wagon.
 				// Indices: [i, i+1] -> diff=1 (Fail). [i, buffer, j] -> diff=2 (Pass)
 				if distance < 2 {
-					return false, fmt.Sprintf("Conflict: Wagon #%d (%s) needs 1 buffer wagon from Wagon #%d (%s)",
-						wagons[i].WagonSpec.Number, codeA, wagons[j].WagonSpec.Number, codeB)
+					findings = append(findings, fmt.Sprintf("Conflict: Wagon #%d (%s) needs 1 buffer wagon from Wagon #%d (%s)",
+						wagons[i].WagonSpec.Number, codeA, wagons[j].WagonSpec.Number, codeB))
 				}
 			case "2":
 				// Needs 2 buffer wagons.
 				// Indices: [i, buff, j] -> diff=2 (Fail). [i, buff, buff, j] -> diff=3 (Pass)
 				if distance < 3 {
-					return false, fmt.Sprintf("Conflict: Wagon #%d (%s) needs 2 buffer wagons from Wagon #%d (%s)",
-						wagons[i].WagonSpec.Number, codeA, wagons[j].WagonSpec.Number, codeB)
+					findings = append(findings, fmt.Sprintf("Conflict: Wagon #%d (%s) needs 2 buffer wagons from Wagon #%d (%s)",
+						wagons[i].WagonSpec.Number, codeA, wagons[j].WagonSpec.Number, codeB))
 				}
 			case "*", "+":
 				// Allowed
+			}
+
+			// No status asks for more than 2 buffer wagons, so a missing rule matters up to there
+			if distance < 3 {
+				for _, pair := range v.missingRules(wagons[i].DangerousCargo(), wagons[j].DangerousCargo()) {
+					findings = append(findings, fmt.Sprintf("No matrix rule for %s: check the separation of Wagon #%d and Wagon #%d by hand",
+						pair, wagons[i].WagonSpec.Number, wagons[j].WagonSpec.Number))
+				}
+			}
+		}
+	}
+
+	return domain.ValidationResult{Passed: len(findings) == 0, Findings: findings}
+}
+
+// strictestRule returns the matrix status of the pair of entries that needs the
+// most separation, with the labels of that pair. Pairs without a rule are left
+// to missingRules.
+func (v *SafetyValidatorService) strictestRule(a, b []domain.DangerousCargo) (status, labelA, labelB string) {
+	best := -1
+	for _, ca := range a {
+		for _, cb := range b {
+			s := v.getRuleStatus(ca.SegregationCode(), cb.SegregationCode())
+			if s == noRule {
 				continue
 			}
+			if d := requiredDistance(s); d > best {
+				best, status, labelA, labelB = d, s, ca.Label(), cb.Label()
+			}
 		}
 	}
+	return status, labelA, labelB
+}
 
-	return true, "All checks passed."
+// requiredDistance is how many buffer wagons a matrix status asks for
+// ("-" forbids adjacency, which one buffer wagon satisfies).
+func requiredDistance(status string) int {
+	switch status {
+	case "-", "1":
+		return 1
+	case "2":
+		return 2
+	}
+	return 0
 }
 
+// missingRules names the pairs of entries the matrix has no rule for, e.g.
+// "UN2912 class 7 and 3a".
+func (v *SafetyValidatorService) missingRules(a, b []domain.DangerousCargo) []string {
+	var pairs []string
+	for _, ca := range a {
+		for _, cb := range b {
+			pair := ca.Label() + " and " + cb.Label()
+			if v.getRuleStatus(ca.SegregationCode(), cb.SegregationCode()) == noRule && !slices.Contains(pairs, pair) {
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+	return pairs
+}
+
+// noRule is the status of a pair of classes the matrix has no rule for. It is
+// reported as such, never turned into a verdict.
+const noRule = "?"
+
 func (v *SafetyValidatorService) getRuleStatus(a, b string) string {
 	if inner, ok := v.rulesMap[a]; ok {
 		if status, ok := inner[b]; ok {
 			return status
 		}
 	}
-	// Fallback or assume forbidden if not found? usually "-"
-	return "-"
+	return noRule
 }
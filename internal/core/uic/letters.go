@@ -0,0 +1,229 @@
+package uic
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// LetterCode is the decoded meaning of a UIC/RIV letter marking such as "Gas" or "Hccrrs".
+// The capital letter is the wagon category, the lower-case index letters add features.
+type LetterCode struct {
+	Code         string
+	Category     string // Capital letter, e.g. "G"
+	CategoryName string
+	MinAxles     int
+	MaxAxles     int
+	MaxSpeed     int      // km/h the marking allows loaded; 0 when not stated
+	Features     []string // Meaning of every index letter that was recognised
+	Unknown      []string // Index letters this decoder does not know
+}
+
+// letterCategory is what a category letter says before any index letter
+type letterCategory struct {
+	name     string
+	digit    byte // First digit of the UIC type code
+	bogies   bool // Bogie wagons by default (R, S) rather than single axles
+	national []string
+}
+
+var letterCategories = map[rune]letterCategory{
+	'E': {"Ordinary open high-sided", '5', false, []string{"Open-High", "Open-Short"}},
+	'F': {"Special open high-sided", '6', false, []string{"Open-High", "Open-Short", "Ballast", "Bulk"}},
+	'G': {"Ordinary covered", '1', false, []string{"Covered"}},
+	'H': {"Special covered", '2', false, []string{"Covered"}},
+	'I': {"Refrigerated", '8', false, []string{"Fridge"}},
+	'K': {"Ordinary flat, single axles", '3', false, []string{"Flat", "Rail Carrier"}},
+	'L': {"Special flat, single axles", '4', false, []string{"Flat", "Rail Carrier"}},
+	'O': {"Open multi-purpose", '3', false, []string{"Open-Short", "Flat"}},
+	'R': {"Ordinary flat, bogies", '3', true, []string{"Flat", "Rail Carrier"}},
+	'S': {"Special flat, bogies", '4', true, []string{"Flat", "Rail Carrier"}},
+	'T': {"Opening roof", '0', false, []string{"Covered", "Bulk"}},
+	'U': {"Special (bulk)", '9', false, []string{"Bulk", "Tank"}},
+	'Z': {"Tank", '7', false, []string{"Tank"}},
+}
+
+// commonIndex holds index letters that mean the same in every category.
+// Category specific meanings in categoryIndex take precedence.
+var commonIndex = map[string]string{
+	"n":  "Increased load limit",
+	"m":  "Long loading length",
+	"mm": "Short loading length",
+	"s":  "Suitable for 100 km/h",
+	"ss": "Suitable for 120 km/h",
+}
+
+// categoryIndex covers the index letters used by the home fleet and common RIV wagons
+var categoryIndex = map[rune]map[string]string{
+	'E': {"l": "Without drop sides", "o": "Non-tipping", "k": "Load limit below 20 t"},
+	'F': {"c": "Bottom discharge", "l": "Controlled gravity discharge", "o": "Side discharge", "t": "Ballast wagon"},
+	'G': {"b": "Large capacity", "bb": "Extra large capacity", "g": "Suitable for two gauges", "k": "Load limit below 20 t", "l": "No side doors"},
+	'H': {"b": "Large capacity", "bb": "Extra large capacity", "c": "End doors", "cc": "Upper deck for road vehicles",
+		"i": "Sliding walls", "ii": "Reinforced sliding walls", "ll": "Lockable partitions", "rr": "Articulated unit"},
+	'I': {"b": "Large capacity", "c": "Ice bunkers", "h": "Mechanical refrigeration"},
+	'K': {"g": "Container capable", "l": "Without stanchions", "p": "Without hand brake"},
+	'L': {"e": "Double deck for cars", "g": "Container capable", "p": "Without hand brake", "rr": "Articulated unit"},
+	'R': {"e": "Stanchions and end walls", "g": "Container capable", "i": "Sliding covers", "o": "End walls fold down"},
+	'S': {"d": "Coil cradles", "g": "Container capable", "h": "Coil transport", "i": "Sliding covers", "p": "Without hand brake"},
+	'T': {"c": "Bottom discharge", "d": "Side discharge", "e": "Opening side walls"},
+	'U': {"c": "Pressure discharge", "h": "Heating"},
+	'Z': {"c": "Bottom discharge", "e": "Heating", "g": "Pressurised gas", "k": "Load limit below 20 t"},
+}
+
+// DecodeLetters expands a letter marking. Index letters are read longest match first,
+// so "ss" is one feature and not "s" twice. Letters the tables do not know are reported
+// in Unknown rather than guessed.
+func DecodeLetters(code string) (*LetterCode, error) {
+	code = strings.TrimSpace(code)
+	runes := []rune(code)
+	if len(runes) == 0 {
+		return nil, fmt.Errorf("empty letter code")
+	}
+	cat, ok := letterCategories[runes[0]]
+	if !ok {
+		return nil, fmt.Errorf("%q does not start with a UIC category letter", code)
+	}
+
+	lc := &LetterCode{Code: code, Category: string(runes[0]), CategoryName: cat.name}
+	lc.MinAxles, lc.MaxAxles = 2, 3
+	if cat.bogies {
+		lc.MinAxles, lc.MaxAxles = 4, 4
+	}
+
+	index := runes[1:]
+	for i := 0; i < len(index); {
+		r := index[i]
+		if !unicode.IsLower(r) {
+			lc.Unknown = append(lc.Unknown, string(r))
+			i++
+			continue
+		}
+		// Letters repeat to change their meaning ("a", "aa", "aaa")
+		n := 1
+		for i+n < len(index) && index[i+n] == r {
+			n++
+		}
+		letters := strings.Repeat(string(r), n)
+		i += n
+
+		switch letters {
+		case "a":
+			if cat.bogies {
+				lc.MinAxles, lc.MaxAxles = 6, 6
+				lc.Features = append(lc.Features, "6 axles")
+			} else {
+				lc.MinAxles, lc.MaxAxles = 4, 4
+				lc.Features = append(lc.Features, "Bogies, 4 axles")
+			}
+			continue
+		case "aa", "aaa":
+			lc.MinAxles, lc.MaxAxles = 6, 12
+			lc.Features = append(lc.Features, "6 axles or more")
+			continue
+		case "s":
+			lc.MaxSpeed = 100
+		case "ss":
+			lc.MaxSpeed = 120
+		case "rr":
+			// Articulated units share bogies, usually 3 axles for two bodies
+			lc.MinAxles, lc.MaxAxles = 3, lc.MaxAxles+3
+		}
+
+		if meaning, ok := categoryIndex[runes[0]][letters]; ok {
+			lc.Features = append(lc.Features, meaning)
+		} else if meaning, ok := commonIndex[letters]; ok {
+			lc.Features = append(lc.Features, meaning)
+		} else {
+			lc.Unknown = append(lc.Unknown, letters)
+		}
+	}
+	return lc, nil
+}
+
+// Describe lists the decoded characteristics, one per line
+func (lc *LetterCode) Describe() string {
+	lines := []string{fmt.Sprintf("%s: %s", lc.Category, lc.CategoryName)}
+	lines = append(lines, "Axles: "+lc.axles())
+	lines = append(lines, lc.Features...)
+	if len(lc.Unknown) > 0 {
+		lines = append(lines, "Not decoded: "+strings.Join(lc.Unknown, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (lc *LetterCode) axles() string {
+	if lc.MaxAxles > lc.MinAxles {
+		return fmt.Sprintf("%d to %d axles", lc.MinAxles, lc.MaxAxles)
+	}
+	return fmt.Sprintf("%d axles", lc.MinAxles)
+}
+
+// CheckLetters reports where a letter marking disagrees with the rest of a wagon spec.
+// key is the catalogue key of the wagon number (see CatalogueKey).
+func CheckLetters(code string, axles int, key int) []string {
+	lc, err := DecodeLetters(code)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	if axles < lc.MinAxles || axles > lc.MaxAxles {
+		problems = append(problems, fmt.Sprintf("%s implies %s, but the spec has %d", code, lc.axles(), axles))
+	}
+
+	cat := letterCategories[[]rune(lc.Category)[0]]
+	switch {
+	case IsNationalKey(key):
+		national := DecodeNationalKey(key).Type
+		if national != "" && !contains(cat.national, national) {
+			problems = append(problems, fmt.Sprintf("wagon #%d is a %s wagon, category %s is %s", key, national, lc.Category, lc.CategoryName))
+		}
+	case IsUICKey(key):
+		digits := fmt.Sprintf("%011d", key)
+		if digits[4] != cat.digit {
+			problems = append(problems, fmt.Sprintf("type code digit %c does not match category %s (expected %c)", digits[4], lc.Category, cat.digit))
+		}
+	}
+	return problems
+}
+
+// typeKeywords recognises the category from the free text wagon type
+var typeKeywords = []struct {
+	keyword    string
+	categories string
+}{
+	{"مسقف", "GHIT"}, {"covered", "GHIT"},
+	{"مخزن", "ZU"}, {"tank", "ZU"},
+	{"مسطح", "KLRS"}, {"flat", "KLRS"},
+	{"یخچال", "I"}, {"fridge", "I"},
+	{"خودرو", "HL"}, {"car carrier", "HL"},
+	{"ریل", "KLRS"}, {"rail", "KLRS"},
+	{"لبه", "EFO"}, {"open", "EFO"},
+}
+
+// CheckLetterType reports when the wagon type text names a different kind of wagon
+func CheckLetterType(code, typeName string) string {
+	lc, err := DecodeLetters(code)
+	if err != nil {
+		return ""
+	}
+	lower := strings.ToLower(typeName)
+	for _, k := range typeKeywords {
+		if strings.Contains(lower, k.keyword) {
+			if strings.Contains(k.categories, lc.Category) {
+				return ""
+			}
+			return fmt.Sprintf("type %q does not fit category %s (%s)", typeName, lc.Category, lc.CategoryName)
+		}
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
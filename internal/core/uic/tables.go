@@ -0,0 +1,93 @@
+package uic
+
+import "strconv"
+
+// interoperability decodes the first digit of a UIC freight wagon number.
+// Domestic numbers (0x, 1x) do not encode the running gear.
+func interoperability(d byte) (string, RunningGear) {
+	switch d {
+	case '0', '1':
+		return "Not interoperable (domestic / private)", GearUnknown
+	case '2':
+		return "TEN / RIV, single axles", GearSingleAxles
+	case '3':
+		return "TEN / RIV, bogies", GearBogies
+	case '4':
+		return "RIV (non-TEN), single axles", GearSingleAxles
+	case '8':
+		return "RIV (non-TEN), bogies", GearBogies
+	}
+	return "Unknown", GearUnknown
+}
+
+type country struct {
+	name   string
+	keeper string
+}
+
+// countries maps UIC country codes to the country and its national railway
+var countries = map[int]country{
+	20: {"Russia", "RZD"}, 21: {"Belarus", "BCh"}, 22: {"Ukraine", "UZ"}, 23: {"Moldova", "CFM"},
+	24: {"Lithuania", "LG"}, 25: {"Latvia", "LDz"}, 26: {"Estonia", "EVR"}, 27: {"Kazakhstan", "KTZ"},
+	28: {"Georgia", "GR"}, 29: {"Uzbekistan", "UTY"},
+	51: {"Poland", "PKP"}, 52: {"Bulgaria", "BDZ"}, 53: {"Romania", "CFR"}, 54: {"Czech Republic", "CD"},
+	55: {"Hungary", "MAV"}, 56: {"Slovakia", "ZSSK"}, 57: {"Azerbaijan", "ADY"},
+	71: {"Spain", "RENFE"}, 72: {"Serbia", "ZS"}, 74: {"Sweden", "SJ"}, 75: {"Turkey", "TCDD"},
+	76: {"Norway", "NSB"}, 78: {"Croatia", "HZ"}, 79: {"Slovenia", "SZ"},
+	80: {"Germany", "DB"}, 81: {"Austria", "OBB"}, 83: {"Italy", "FS"}, 84: {"Netherlands", "NS"},
+	85: {"Switzerland", "SBB"}, 86: {"Denmark", "DSB"}, 87: {"France", "SNCF"}, 88: {"Belgium", "SNCB"},
+	94: {"Portugal", "CP"}, 96: {"Iran", "RAI"}, 97: {"Syria", "CFS"}, 99: {"Iraq", "IRR"},
+}
+
+type category struct {
+	letter string
+	name   string
+}
+
+// categories maps the first digit of the type code to the UIC wagon category
+var categories = map[byte]category{
+	'0': {"T", "Opening roof"},
+	'1': {"G", "Ordinary covered"},
+	'2': {"H", "Special covered"},
+	'3': {"K/R", "Ordinary flat"},
+	'4': {"L/S", "Special flat"},
+	'5': {"E", "Ordinary open high-sided"},
+	'6': {"F", "Special open high-sided"},
+	'7': {"Z", "Tank"},
+	'8': {"I", "Refrigerated"},
+	'9': {"U", "Special (bulk)"},
+}
+
+// National is what the digits of a 6-digit national number tell about the wagon.
+type National struct {
+	Type  string // Empty when the first digit is unknown
+	Axles int    // 0 when the third digit is unknown
+}
+
+// Decoder maps ported from Wagons.py logic
+var nationalTypes = map[byte]string{
+	'1': "Covered", '2': "Open-Short", '3': "Open-High", '4': "Flat", '5': "Tank",
+	'6': "Rail Carrier", '7': "Fridge", '8': "Ballast", '9': "Bulk",
+}
+var nationalAxles = map[byte]int{
+	'0': 3, '1': 2, '2': 4, '3': 4, '4': 4,
+	'8': 6, '9': 6,
+}
+
+// DecodeNational reads the type (first digit) and axles (third digit) of a national number.
+// It works on incomplete input, so it can follow the operator while typing.
+func DecodeNational(s string) National {
+	var n National
+	if len(s) >= 1 {
+		n.Type = nationalTypes[s[0]]
+	}
+	if len(s) >= 3 {
+		n.Axles = nationalAxles[s[2]]
+	}
+	return n
+}
+
+// DecodeNationalKey decodes a national catalogue key
+func DecodeNationalKey(key int) National {
+	return DecodeNational(strconv.Itoa(key))
+}
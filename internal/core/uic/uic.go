@@ -0,0 +1,230 @@
+// Package uic parses and validates wagon numbers: the 6-digit national numbers
+// of the home fleet and the 12-digit UIC numbers carried by cross-border wagons.
+//
+// A UIC number is laid out as
+//
+//	II CC TTTT SSS-K
+//
+// II interoperability code, CC owner country, TTTT type code (the first digit is
+// the wagon category), SSS serial and K the Luhn check digit over the first 11 digits.
+package uic
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	NationalDigits = 6
+	UICDigits      = 12
+)
+
+var (
+	ErrFormat     = errors.New("wagon number must have 6 (national) or 12 (UIC) digits")
+	ErrCheckDigit = errors.New("UIC check digit does not match")
+)
+
+// RunningGear is what the interoperability code tells about the axles.
+type RunningGear string
+
+const (
+	GearUnknown     RunningGear = ""             // Not encoded, e.g. in domestic numbers
+	GearSingleAxles RunningGear = "Single axles" // 2 or 3 axles
+	GearBogies      RunningGear = "Bogies"       // 4 axles or more
+)
+
+// Number is a decoded 12-digit UIC wagon number.
+type Number struct {
+	Digits string // All 12 digits, without separators
+
+	Interoperability string // Meaning of the interoperability code
+	Gear             RunningGear
+	CountryCode      int
+	Country          string // Empty when the code is unknown
+	Keeper           string // Railway registering wagons of that country
+	Category         string // UIC category letter, e.g. "G"
+	CategoryName     string
+	Serial           string
+	CheckDigit       int
+}
+
+// Normalize strips the spaces, dashes and dots wagon numbers are printed with.
+func Normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+}
+
+// CheckDigit computes the Luhn check digit over the first 11 digits of a UIC number.
+// Digits are weighted 2, 1, 2, 1, ... from the left and the digits of the products summed.
+func CheckDigit(body string) (int, error) {
+	if len(body) != UICDigits-1 || !allDigits(body) {
+		return 0, fmt.Errorf("check digit needs %d digits, got %q", UICDigits-1, body)
+	}
+	sum := 0
+	for i, r := range body {
+		d := int(r - '0')
+		if i%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10, nil
+}
+
+// Parse validates a 12-digit UIC number, including its check digit, and decodes it.
+func Parse(s string) (*Number, error) {
+	digits := Normalize(s)
+	if len(digits) != UICDigits || !allDigits(digits) {
+		return nil, fmt.Errorf("UIC number must have %d digits", UICDigits)
+	}
+	want, _ := CheckDigit(digits[:UICDigits-1])
+	got := int(digits[UICDigits-1] - '0')
+	if want != got {
+		return nil, fmt.Errorf("%w: got %d, expected %d", ErrCheckDigit, got, want)
+	}
+
+	n := &Number{Digits: digits, Serial: digits[8:11], CheckDigit: got}
+	n.Interoperability, n.Gear = interoperability(digits[0])
+	n.CountryCode, _ = strconv.Atoi(digits[2:4])
+	if c, ok := countries[n.CountryCode]; ok {
+		n.Country, n.Keeper = c.name, c.keeper
+	}
+	if c, ok := categories[digits[4]]; ok {
+		n.Category, n.CategoryName = c.letter, c.name
+	}
+	return n, nil
+}
+
+// String prints the number in the usual "II CC TTTT SSS-K" grouping.
+func (n *Number) String() string {
+	return Format(n.Digits)
+}
+
+// Describe summarises a complete UIC number in one line
+func (n *Number) Describe() string {
+	parts := []string{n.String()}
+	if n.Country != "" {
+		parts = append(parts, n.Country+" ("+n.Keeper+")")
+	} else {
+		parts = append(parts, "Country "+strconv.Itoa(n.CountryCode))
+	}
+	if n.Category != "" {
+		parts = append(parts, "Category "+n.Category+" "+n.CategoryName)
+	}
+	if n.Gear != GearUnknown {
+		parts = append(parts, string(n.Gear))
+	}
+	return strings.Join(append(parts, "Check digit OK"), " | ")
+}
+
+// AxleRange is the axle count the interoperability code allows. ok is false
+// when the code does not tell, as for domestic numbers.
+func (n *Number) AxleRange() (min, max int, ok bool) {
+	switch n.Gear {
+	case GearBogies:
+		return 4, 8, true
+	case GearSingleAxles:
+		return 2, 3, true
+	}
+	return 0, 0, false
+}
+
+// Format groups 12 digits as "II CC TTTT SSS-K". Other input is returned unchanged.
+func Format(digits string) string {
+	if len(digits) != UICDigits {
+		return digits
+	}
+	return fmt.Sprintf("%s %s %s %s-%s", digits[0:2], digits[2:4], digits[4:8], digits[8:11], digits[11:])
+}
+
+// FormatNumber prints a wagon number, grouping it when it is a UIC number.
+func FormatNumber(number int) string {
+	if number >= 1e10 && number < 1e12 {
+		return Format(fmt.Sprintf("%012d", number))
+	}
+	return strconv.Itoa(number)
+}
+
+// CatalogueKey maps a wagon number to the key it is stored under in the catalogue.
+// National numbers are their own key. UIC numbers are checked and stored without
+// the check digit; an 11-digit value is a UIC number whose leading zero was lost
+// when it was converted to an integer.
+func CatalogueKey(number int) (int, error) {
+	s := strconv.Itoa(number)
+	switch len(s) {
+	case NationalDigits:
+		return number, nil
+	case UICDigits - 1, UICDigits:
+		n, err := Parse(fmt.Sprintf("%012d", number))
+		if err != nil {
+			return 0, err
+		}
+		return strconv.Atoi(n.Digits[:UICDigits-1])
+	}
+	return 0, ErrFormat
+}
+
+// IsNationalKey reports whether a catalogue key is a national 6-digit number.
+func IsNationalKey(key int) bool {
+	return key >= 100000 && key <= 999999
+}
+
+// IsUICKey reports whether a catalogue key is the 11-digit body of a UIC number.
+func IsUICKey(key int) bool {
+	return key >= 1e9 && key < 1e11
+}
+
+// FormatKey prints a catalogue key as the wagon number it stands for.
+func FormatKey(key int) string {
+	if !IsUICKey(key) {
+		return strconv.Itoa(key)
+	}
+	body := fmt.Sprintf("%011d", key)
+	check, _ := CheckDigit(body)
+	return Format(body + strconv.Itoa(check))
+}
+
+// ParseEntry reads a wagon number as typed by the operator.
+// complete is false while the input is still too short to look up.
+func ParseEntry(s string) (number int, complete bool, err error) {
+	digits := Normalize(s)
+	if digits == "" {
+		return 0, false, nil
+	}
+	if !allDigits(digits) {
+		return 0, false, errors.New("wagon number may only contain digits")
+	}
+	switch {
+	case len(digits) == NationalDigits:
+		number, _ = strconv.Atoi(digits)
+		return number, true, nil
+	case len(digits) == UICDigits:
+		if _, err := Parse(digits); err != nil {
+			return 0, false, err
+		}
+		number, _ = strconv.Atoi(digits)
+		return number, true, nil
+	case len(digits) > UICDigits:
+		return 0, false, ErrFormat
+	}
+	return 0, false, nil
+}
+
+func allDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,103 @@
+// Package units holds the physical quantities of a train calculation. Each
+// quantity has its own type, so tonnes cannot be added to metres and a value
+// read in kilograms or millimetres has to be converted on the way in.
+//
+// The types work like time.Duration: multiply a number by a unit constant to
+// build a value (12*units.Tonne, 840*units.Millimetre) and call the unit
+// method to read it back (m.Tonnes(), l.Millimetres()).
+package units
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mass is stored in tonnes.
+type Mass float64
+
+const (
+	Kilogram Mass = 0.001
+	Tonne    Mass = 1
+)
+
+func Tonnes(t float64) Mass     { return Mass(t) * Tonne }
+func Kilograms(kg float64) Mass { return Mass(kg) * Kilogram }
+
+func (m Mass) Tonnes() float64    { return float64(m / Tonne) }
+func (m Mass) Kilograms() float64 { return float64(m / Kilogram) }
+func (m Mass) String() string     { return fmt.Sprintf("%.1f t", m.Tonnes()) }
+
+// Length is stored in metres.
+type Length float64
+
+const (
+	Millimetre Length = 0.001
+	Centimetre Length = 0.01
+	Metre      Length = 1
+	Kilometre  Length = 1000
+)
+
+func Metres(m float64) Length       { return Length(m) * Metre }
+func Millimetres(mm float64) Length { return Length(mm) * Millimetre }
+func Kilometres(km float64) Length  { return Length(km) * Kilometre }
+
+func (l Length) Metres() float64      { return float64(l / Metre) }
+func (l Length) Millimetres() float64 { return float64(l / Millimetre) }
+func (l Length) Kilometres() float64  { return float64(l / Kilometre) }
+func (l Length) String() string       { return fmt.Sprintf("%.1f m", l.Metres()) }
+
+// Speed is stored in km/h.
+type Speed float64
+
+const (
+	KilometrePerHour Speed = 1
+	MetrePerSecond   Speed = 3.6
+)
+
+func KmPerHour(v float64) Speed { return Speed(v) * KilometrePerHour }
+
+func (v Speed) KmPerHour() float64       { return float64(v / KilometrePerHour) }
+func (v Speed) MetresPerSecond() float64 { return float64(v / MetrePerSecond) }
+func (v Speed) String() string           { return fmt.Sprintf("%.0f km/h", v.KmPerHour()) }
+
+// Gradient is stored in permil, falling positive in the direction of travel.
+type Gradient float64
+
+const (
+	PerMil  Gradient = 1
+	Percent Gradient = 10
+)
+
+func Permil(p float64) Gradient { return Gradient(p) * PerMil }
+
+func (g Gradient) Permil() float64 { return float64(g / PerMil) }
+
+// Ratio is the rise over the run, e.g. 0.02 for 20 permil
+func (g Gradient) Ratio() float64 { return g.Permil() / 1000 }
+func (g Gradient) String() string { return fmt.Sprintf("%.1f ‰", g.Permil()) }
+
+// ParseMassUnit reads the unit of a spreadsheet or CSV column ("t", "kg").
+func ParseMassUnit(s string) (Mass, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "t", "ton", "tons", "tonne", "tonnes":
+		return Tonne, nil
+	case "kg", "kilogram", "kilograms":
+		return Kilogram, nil
+	}
+	return 0, fmt.Errorf("unknown mass unit %q", s)
+}
+
+// ParseLengthUnit reads the unit of a spreadsheet or CSV column ("mm", "m", "km").
+func ParseLengthUnit(s string) (Length, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "mm", "millimetre", "millimetres":
+		return Millimetre, nil
+	case "cm", "centimetre", "centimetres":
+		return Centimetre, nil
+	case "m", "metre", "metres", "meter", "meters":
+		return Metre, nil
+	case "km", "kilometre", "kilometres":
+		return Kilometre, nil
+	}
+	return 0, fmt.Errorf("unknown length unit %q", s)
+}
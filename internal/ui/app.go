@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"railguard/internal/adapter/signature"
 	"railguard/internal/core/domain"
 	"railguard/internal/core/ports"
 	"railguard/internal/core/services"
+	"railguard/internal/core/units"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -14,16 +16,21 @@ type App struct {
 	FyneApp    fyne.App
 	MainWindow fyne.Window
 
-	WagonRepo  ports.WagonRepository
-	Calculator *services.BrakeCalculatorService
-	Validator  *services.SafetyValidatorService
+	WagonRepo   ports.WagonRepository
+	LicenseRepo ports.LicenseRepository
+	DefectRepo  ports.DefectRepository
+	GoodsRepo   ports.DangerousGoodsRepository
+	Calculator  *services.BrakeCalculatorService
+	Validator   *services.SafetyValidatorService
+	Signer      *signature.Signer // Examiner key used to sign licenses (nil = unsigned)
 
-	CurrentTrain []domain.SelectedWagon
-	CurrentLocos []domain.Locomotive
-	CurrentSlope int
+	Composition   *services.CompositionService // Working train: the screens render it and send it commands
+	CurrentRegime domain.BrakeRegime           // G/P position the braking curve is computed for
+
+	CurrentProfile *domain.GradientProfile // Route gradient profile, replaces the slope entry when loaded
 }
 
-func NewApp(wRepo ports.WagonRepository, calc *services.BrakeCalculatorService, val *services.SafetyValidatorService) *App {
+func NewApp(wRepo ports.WagonRepository, lRepo ports.LicenseRepository, dRepo ports.DefectRepository, gRepo ports.DangerousGoodsRepository, calc *services.BrakeCalculatorService, val *services.SafetyValidatorService, signer *signature.Signer) *App {
 	// os.Setenv("FYNE_FONT", "./assets/Vazir.ttf")
 
 	myApp := app.New()
@@ -34,12 +41,17 @@ func NewApp(wRepo ports.WagonRepository, calc *services.BrakeCalculatorService,
 	myWindow := myApp.NewWindow("RailGuard Pro - Train Safety System")
 
 	application := &App{
-		FyneApp:      myApp,
-		MainWindow:   myWindow,
-		WagonRepo:    wRepo,
-		Calculator:   calc,
-		Validator:    val,
-		CurrentSlope: 10,
+		FyneApp:       myApp,
+		MainWindow:    myWindow,
+		WagonRepo:     wRepo,
+		LicenseRepo:   lRepo,
+		DefectRepo:    dRepo,
+		GoodsRepo:     gRepo,
+		Calculator:    calc,
+		Validator:     val,
+		Signer:        signer,
+		Composition:   services.NewCompositionService(10 * units.PerMil),
+		CurrentRegime: domain.RegimeGoods,
 	}
 
 	dashboard := application.makeDashboard()
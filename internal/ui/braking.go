@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/units"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+var brakingChartSize = fyne.NewSize(460, 220)
+
+// attachBraking adds the braking curve for the current slope and regime to res
+func (a *App) attachBraking(train *domain.Train, res *domain.CalculationResult) {
+	curve := a.Calculator.BrakingCurve(train, a.Composition.Slope(), a.CurrentRegime, res.MaxSpeed)
+	res.Braking = &curve
+}
+
+// brakingSummary is the one-paragraph text of a braking curve
+func brakingSummary(curve *domain.BrakingCurve) string {
+	if curve == nil {
+		return "Add wagons to see the braking curve."
+	}
+	head := fmt.Sprintf("Regime %s | Gradient %.0f ‰ | Build-up %.1f s", curve.Regime.Label(), curve.Gradient.Permil(), curve.BuildUpTime)
+	if !curve.CanStop {
+		return head + "\n❌ The brakes cannot hold the train on this gradient."
+	}
+	var lines []string
+	for _, p := range curve.Points[1:] {
+		lines = append(lines, fmt.Sprintf("%3.0f km/h → %4.0f m", p.Speed.KmPerHour(), p.Distance.Metres()))
+	}
+	return fmt.Sprintf("%s | Deceleration %.2f m/s²\n%s", head, curve.Deceleration, strings.Join(lines, "\n"))
+}
+
+// brakingView is the dashboard tab with the stopping distance chart of the working train
+type brakingView struct {
+	chart   *fyne.Container
+	summary *widget.Label
+}
+
+func newBrakingView() *brakingView {
+	b := &brakingView{chart: container.NewWithoutLayout(), summary: widget.NewLabel("")}
+	b.Update(nil)
+	return b
+}
+
+func (b *brakingView) Object() fyne.CanvasObject {
+	return container.NewVScroll(container.NewVBox(container.NewGridWrap(brakingChartSize, b.chart), b.summary))
+}
+
+// Update redraws the chart; a nil curve clears it
+func (b *brakingView) Update(curve *domain.BrakingCurve) {
+	b.summary.SetText(brakingSummary(curve))
+	b.chart.RemoveAll()
+	defer b.chart.Refresh()
+
+	const left, bottom, pad = 50, 30, 10
+	w, h := brakingChartSize.Width-left-pad, brakingChartSize.Height-bottom-pad
+	axis := theme.Color(theme.ColorNameForeground)
+	origin := fyne.NewPos(left, pad+h)
+	b.chart.Add(&canvas.Line{StrokeColor: axis, StrokeWidth: 1, Position1: origin, Position2: fyne.NewPos(left+w, pad+h)})
+	b.chart.Add(&canvas.Line{StrokeColor: axis, StrokeWidth: 1, Position1: origin, Position2: fyne.NewPos(left, pad)})
+
+	if curve == nil || !curve.CanStop || len(curve.Points) < 2 {
+		return
+	}
+	last := curve.Points[len(curve.Points)-1]
+	maxDist := units.Metres(niceCeil(last.Distance.Metres()))
+	x := func(speed units.Speed) float32 { return left + w*float32(speed/last.Speed) }
+	y := func(dist units.Length) float32 { return pad + h - h*float32(dist/maxDist) }
+
+	addText := func(text string, pos fyne.Position) {
+		t := canvas.NewText(text, axis)
+		t.TextSize = 10
+		t.Move(pos)
+		b.chart.Add(t)
+	}
+	addText(fmt.Sprintf("%.0f m", maxDist.Metres()), fyne.NewPos(0, pad-6))
+	addText("0", fyne.NewPos(left-12, pad+h-6))
+	addText(last.Speed.String(), fyne.NewPos(left+w-40, pad+h+6))
+
+	line := color.NRGBA{R: 0xe5, G: 0x39, B: 0x35, A: 0xff}
+	for i := 1; i < len(curve.Points); i++ {
+		p0, p1 := curve.Points[i-1], curve.Points[i]
+		b.chart.Add(&canvas.Line{StrokeColor: line, StrokeWidth: 2,
+			Position1: fyne.NewPos(x(p0.Speed), y(p0.Distance)), Position2: fyne.NewPos(x(p1.Speed), y(p1.Distance))})
+	}
+}
+
+// niceCeil rounds a distance up to the next 1, 2 or 5 step for the chart axis
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	step := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if v <= m*step {
+			return m * step
+		}
+	}
+	return 10 * step
+}
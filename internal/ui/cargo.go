@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"railguard/internal/core/domain"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// dangerCodes are the class codes offered for cargo without a UN number. The
+// compatibility matrix counts each by its main class, see DangerousCargo.SegregationCode
+var dangerCodes = []string{"1", "2b", "2a", "2at", "3a", "3bc", "4-1", "4-2", "4-3", "5-1", "5-2", "6-1", "6-1 HCN", "6-2", "7", "8", "9"}
+
+// cargoEditor edits the dangerous goods entries of one wagon
+type cargoEditor struct {
+	a        *App
+	entries  []domain.DangerousCargo
+	list     *fyne.Container
+	addBtn   *widget.Button
+	enabled  bool
+	onChange func()
+}
+
+func (a *App) newCargoEditor(entries []domain.DangerousCargo, onChange func()) *cargoEditor {
+	c := &cargoEditor{a: a, entries: append([]domain.DangerousCargo(nil), entries...), list: container.NewVBox(), onChange: onChange}
+	c.addBtn = widget.NewButtonWithIcon("Add Dangerous Goods", theme.ContentAddIcon(), c.showAdd)
+	c.SetEnabled(false)
+	return c
+}
+
+func (c *cargoEditor) Object() fyne.CanvasObject {
+	return container.NewVBox(c.list, c.addBtn)
+}
+
+// Entries returns the entries as edited
+func (c *cargoEditor) Entries() []domain.DangerousCargo {
+	return c.entries
+}
+
+func (c *cargoEditor) SetEnabled(enabled bool) {
+	c.enabled = enabled
+	if enabled {
+		c.addBtn.Enable()
+	} else {
+		c.addBtn.Disable()
+	}
+	c.rebuild()
+}
+
+func (c *cargoEditor) rebuild() {
+	c.list.RemoveAll()
+	for i, entry := range c.entries {
+		idx := i
+		text := cargoText(entry)
+		removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+			c.entries = append(c.entries[:idx], c.entries[idx+1:]...)
+			c.rebuild()
+			c.onChange()
+		})
+		if !c.enabled {
+			removeBtn.Disable()
+		}
+		label := widget.NewLabel(text)
+		label.Wrapping = fyne.TextWrapWord
+		c.list.Add(container.NewBorder(nil, nil, nil, removeBtn, label))
+	}
+	c.list.Refresh()
+}
+
+// showAdd asks for one entry: a UN number from the shipping documents, or a bare class code
+func (c *cargoEditor) showAdd() {
+	classSelect := widget.NewSelect(dangerCodes, nil)
+	classSelect.PlaceHolder = "Class code (no UN number)"
+	unEntry := widget.NewEntry()
+	unEntry.SetPlaceHolder("UN number, e.g. 1203")
+	unInfo := widget.NewLabel("")
+	unInfo.Wrapping = fyne.TextWrapWord
+	unEntry.OnChanged = func(s string) {
+		if strings.TrimSpace(s) == "" {
+			unInfo.SetText("")
+			classSelect.Enable()
+			return
+		}
+		classSelect.Disable()
+		if g, err := c.a.lookupGoods(s); err == nil {
+			unInfo.SetText(goodsSummary(g))
+		} else {
+			unInfo.SetText("❌ " + err.Error())
+		}
+	}
+	quantityEntry := widget.NewEntry()
+	quantityEntry.SetPlaceHolder("kg or litres")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("UN Number:", container.NewVBox(unEntry, unInfo)),
+		widget.NewFormItem("or Class:", classSelect),
+		widget.NewFormItem("Quantity:", quantityEntry),
+	}
+	form := dialog.NewForm("Add Dangerous Goods", "Add", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		entry, err := c.readEntry(unEntry.Text, classSelect.Selected, quantityEntry.Text)
+		if err != nil {
+			c.a.ShowError(err)
+			return
+		}
+		c.entries = append(c.entries, entry)
+		c.rebuild()
+		c.onChange()
+	}, c.a.MainWindow)
+	form.Resize(fyne.NewSize(420, 380))
+	form.Show()
+}
+
+func (c *cargoEditor) readEntry(un, class, quantity string) (domain.DangerousCargo, error) {
+	var entry domain.DangerousCargo
+	if q := strings.TrimSpace(quantity); q != "" {
+		v, err := strconv.ParseFloat(q, 64)
+		if err != nil || v < 0 {
+			return entry, fmt.Errorf("invalid quantity %q", q)
+		}
+		entry.Quantity = v
+	}
+
+	if strings.TrimSpace(un) != "" {
+		g, err := c.a.lookupGoods(un)
+		if err != nil {
+			return entry, err
+		}
+		entry.Goods = g
+		entry.Code = g.Class
+		return entry, nil
+	}
+	if class == "" {
+		return entry, errors.New("enter a UN number or pick a class code")
+	}
+	entry.Code = class
+	return entry, nil
+}
+
+func cargoText(entry domain.DangerousCargo) string {
+	text := "Class " + entry.Code
+	if entry.Goods != nil {
+		text = entry.Goods.Label()
+	}
+	if entry.Quantity > 0 {
+		text += fmt.Sprintf(" | %.0f", entry.Quantity)
+	}
+	return text
+}
@@ -0,0 +1,367 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"railguard/internal/adapter/storage/sqlite"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/uic"
+	"railguard/internal/core/units"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// prefCatalogueEditor remembers who last edited the catalogue on this device
+const prefCatalogueEditor = "catalogue.changed_by"
+
+// parseCatalogueNumber reads a range end typed as a national or full UIC number
+func parseCatalogueNumber(s string) (int, error) {
+	number, complete, err := uic.ParseEntry(s)
+	if err != nil {
+		return 0, err
+	}
+	if !complete {
+		return 0, uic.ErrFormat
+	}
+	return uic.CatalogueKey(number)
+}
+
+func (a *App) showWagonCatalogue() {
+	repo, ok := a.WagonRepo.(*sqlite.WagonRepository)
+	if !ok {
+		return
+	}
+
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder("Type, RIV code or wagon number")
+	status := widget.NewLabel("")
+	var ranges []domain.WagonRange
+
+	list := widget.NewList(
+		func() int { return len(ranges) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("Range", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel("Details"),
+			)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			rg := ranges[i]
+			box := o.(*fyne.Container)
+			title := fmt.Sprintf("%s - %s | %s (%d wagons)", uic.FormatKey(rg.From), uic.FormatKey(rg.To), rg.Spec.Type, rg.Size())
+			if rg.Retired {
+				title = "⛔ RETIRED | " + title
+			}
+			box.Objects[0].(*widget.Label).SetText(title)
+			box.Objects[1].(*widget.Label).SetText(fmt.Sprintf("RIV %s | %d axles | %.1f / %.1f t | Brake %.1f / %.1f t | %s",
+				rg.Spec.RIVCode, rg.Spec.Axles, rg.Spec.WeightEmpty.Tonnes(), rg.Spec.WeightLoaded.Tonnes(),
+				rg.Spec.BrakeWeightEmpty.Tonnes(), rg.Spec.BrakeWeightLoaded.Tonnes(), rg.Spec.Manufacturer))
+		},
+	)
+
+	search := func() {
+		var err error
+		ranges, err = repo.ListWagonRanges(searchEntry.Text)
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		status.SetText(fmt.Sprintf("%d catalogue entries", len(ranges)))
+		list.Refresh()
+	}
+	searchEntry.OnSubmitted = func(string) { search() }
+
+	list.OnSelected = func(id widget.ListItemID) {
+		rg := ranges[id]
+		list.Unselect(id)
+		a.openWagonRangeForm(repo, &rg, search)
+	}
+
+	searchBtn := widget.NewButtonWithIcon("", theme.SearchIcon(), search)
+	addBtn := widget.NewButtonWithIcon("Add Range", theme.ContentAddIcon(), func() {
+		a.openWagonRangeForm(repo, nil, search)
+	})
+	addBtn.Importance = widget.HighImportance
+	auditBtn := widget.NewButtonWithIcon("Change Log", theme.ListIcon(), func() {
+		a.showCatalogueAudit(repo)
+	})
+
+	top := container.NewVBox(
+		container.NewBorder(nil, nil, nil, searchBtn, searchEntry),
+		container.NewHBox(addBtn, auditBtn),
+		status,
+	)
+	d := dialog.NewCustom("Wagon Catalogue", "Close", container.NewBorder(top, nil, nil, nil, list), a.MainWindow)
+	d.Resize(fyne.NewSize(650, 700))
+	d.Show()
+	search()
+}
+
+// openWagonRangeForm adds a catalogue range (old == nil) or edits and retires an existing one
+func (a *App) openWagonRangeForm(repo *sqlite.WagonRepository, old *domain.WagonRange, refresh func()) {
+	var rg domain.WagonRange
+	if old != nil {
+		rg = *old
+	}
+	w := &rg.Spec
+
+	fromEntry := widget.NewEntry()
+	toEntry := widget.NewEntry()
+	if old != nil {
+		fromEntry.SetText(uic.FormatKey(rg.From))
+		toEntry.SetText(uic.FormatKey(rg.To))
+	}
+	axlesEntry := intEntry(w.Axles)
+	editorEntry := widget.NewEntry()
+	editorEntry.SetText(a.FyneApp.Preferences().String(prefCatalogueEditor))
+	editorEntry.SetPlaceHolder("Your name")
+
+	// Specification fields, bound to the struct when the form is applied
+	type textField struct {
+		label string
+		entry *widget.Entry
+		value *string
+	}
+	type numField struct {
+		label string
+		entry *widget.Entry
+		get   func() float64
+		set   func(float64)
+	}
+	// Each field is entered in the unit of its label
+	tonnes := func(label string, m *units.Mass) numField {
+		return numField{label: label, get: m.Tonnes, set: func(v float64) { *m = units.Tonnes(v) }}
+	}
+	metres := func(label string, l *units.Length) numField {
+		return numField{label: label, get: l.Metres, set: func(v float64) { *l = units.Metres(v) }}
+	}
+	millimetres := func(label string, l *units.Length) numField {
+		return numField{label: label, get: l.Millimetres, set: func(v float64) { *l = units.Millimetres(v) }}
+	}
+	cubicMetres := func(label string, v *float64) numField {
+		return numField{label: label, get: func() float64 { return *v }, set: func(n float64) { *v = n }}
+	}
+	texts := []textField{
+		{"Type:", nil, &w.Type}, {"RIV Code:", nil, &w.RIVCode}, {"Manufacturer:", nil, &w.Manufacturer},
+		{"Year:", nil, &w.Year}, {"Bogie:", nil, &w.BogieType}, {"Bearing:", nil, &w.BearingType},
+		{"Springs:", nil, &w.SpringType}, {"Hand Brake:", nil, &w.HandBrakeType},
+		{"Triple Valve:", nil, &w.ControlValveType}, {"Brake Cylinder:", nil, &w.BrakeCylinderType},
+		{"Coupling:", nil, &w.CouplingType},
+	}
+	nums := []numField{
+		tonnes("Empty Weight (t):", &w.WeightEmpty), tonnes("Loaded Weight (t):", &w.WeightLoaded),
+		tonnes("Max Load (t):", &w.MaxCapacity), cubicMetres("Volume (m3):", &w.LoadVolume),
+		tonnes("Brake Empty (t):", &w.BrakeWeightEmpty), tonnes("Brake Loaded (t):", &w.BrakeWeightLoaded),
+		tonnes("Hand Brake (t):", &w.HandBrakeWeight), metres("Length (m):", &w.Length),
+		metres("Load Length (m):", &w.LoadLength), metres("Load Width (m):", &w.LoadWidth),
+		metres("Floor Height (m):", &w.FloorHeight), metres("Internal Height (m):", &w.InternalHeight),
+		metres("Pivot Distance (m):", &w.BogiePivotDistance), millimetres("Wheel (mm):", &w.WheelDiameter),
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("From No:", fromEntry),
+		widget.NewFormItem("To No:", toEntry),
+		widget.NewFormItem("Axles:", axlesEntry),
+	)
+	for i := range texts {
+		texts[i].entry = widget.NewEntry()
+		texts[i].entry.SetText(*texts[i].value)
+		form.Append(texts[i].label, texts[i].entry)
+	}
+	for i := range nums {
+		nums[i].entry = widget.NewEntry()
+		nums[i].entry.SetText(strconv.FormatFloat(nums[i].get(), 'f', -1, 64))
+		form.Append(nums[i].label, nums[i].entry)
+	}
+
+	// read copies the entries into rg and reports every field that is not a number
+	read := func() error {
+		var bad []string
+		parseInt := func(label string, e *widget.Entry, v *int) {
+			n, err := strconv.Atoi(strings.TrimSpace(e.Text))
+			if err != nil {
+				bad = append(bad, label)
+			}
+			*v = n
+		}
+		parseInt("Axles", axlesEntry, &w.Axles)
+		var err error
+		if rg.From, err = parseCatalogueNumber(fromEntry.Text); err != nil {
+			return fmt.Errorf("From No: %w", err)
+		}
+		if rg.To, err = parseCatalogueNumber(toEntry.Text); err != nil {
+			return fmt.Errorf("To No: %w", err)
+		}
+		for _, f := range texts {
+			*f.value = strings.TrimSpace(f.entry.Text)
+		}
+		for _, f := range nums {
+			s := strings.TrimSpace(f.entry.Text)
+			if s == "" {
+				s = "0"
+			}
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				bad = append(bad, strings.TrimSuffix(f.label, ":"))
+			}
+			f.set(v)
+		}
+		if len(bad) > 0 {
+			return fmt.Errorf("not a number: %s", strings.Join(bad, ", "))
+		}
+		return rg.Validate()
+	}
+
+	var d dialog.Dialog
+	changedBy := func() (string, bool) {
+		name := strings.TrimSpace(editorEntry.Text)
+		if name == "" {
+			a.ShowError(errors.New("enter your name, every catalogue change is logged"))
+			return "", false
+		}
+		a.FyneApp.Preferences().SetString(prefCatalogueEditor, name)
+		return name, true
+	}
+
+	saveBtn := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		name, ok := changedBy()
+		if !ok {
+			return
+		}
+		if err := read(); err != nil {
+			a.ShowError(err)
+			return
+		}
+		if err := repo.SaveWagonRange(old, rg, name); err != nil {
+			a.ShowError(err)
+			return
+		}
+		refresh()
+		d.Hide()
+	})
+	saveBtn.Importance = widget.HighImportance
+	buttons := container.NewHBox(saveBtn)
+
+	title := "New Catalogue Range"
+	if old != nil {
+		title = fmt.Sprintf("Catalogue %s - %s", uic.FormatKey(old.From), uic.FormatKey(old.To))
+		label, icon := "Retire", theme.DeleteIcon()
+		if old.Retired {
+			label, icon = "Reactivate", theme.ViewRefreshIcon()
+		}
+		retireBtn := widget.NewButtonWithIcon(label, icon, func() {
+			name, ok := changedBy()
+			if !ok {
+				return
+			}
+			if err := repo.SetWagonRangeRetired(*old, !old.Retired, name); err != nil {
+				a.ShowError(err)
+				return
+			}
+			refresh()
+			d.Hide()
+		})
+		if !old.Retired {
+			retireBtn.Importance = widget.DangerImportance
+		}
+		buttons.Add(retireBtn)
+	}
+
+	top := widget.NewForm(widget.NewFormItem("Changed By:", editorEntry))
+	content := container.NewBorder(top, buttons, nil, nil, container.NewVScroll(form))
+	d = dialog.NewCustom(title, "Cancel", content, a.MainWindow)
+	d.Resize(fyne.NewSize(500, 700))
+	d.Show()
+}
+
+func (a *App) showCatalogueAudit(repo *sqlite.WagonRepository) {
+	changes, err := repo.ListCatalogueChanges()
+	if err != nil {
+		a.ShowError(err)
+		return
+	}
+	if len(changes) == 0 {
+		a.ShowInfo("Change Log", "The catalogue has not been changed in the application yet.")
+		return
+	}
+
+	list := widget.NewList(
+		func() int { return len(changes) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("Change", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel("Details"),
+			)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			c := changes[i]
+			box := o.(*fyne.Container)
+			box.Objects[0].(*widget.Label).SetText(fmt.Sprintf("%s | %s by %s",
+				c.ChangedAt.Format("2006-01-02 15:04"), strings.ToUpper(c.Action), c.ChangedBy))
+			box.Objects[1].(*widget.Label).SetText(describeCatalogueChange(c))
+		},
+	)
+
+	d := dialog.NewCustom("Catalogue Change Log", "Close", list, a.MainWindow)
+	d.Resize(fyne.NewSize(600, 600))
+	d.Show()
+}
+
+// describeCatalogueChange lists the range and spec fields that differ between before and after
+func describeCatalogueChange(c domain.CatalogueChange) string {
+	if c.After == nil {
+		return "-"
+	}
+	after := *c.After
+	if c.Before == nil {
+		return fmt.Sprintf("Added %s - %s (%s)", uic.FormatKey(after.From), uic.FormatKey(after.To), after.Spec.Type)
+	}
+	before := *c.Before
+
+	var diffs []string
+	if before.From != after.From || before.To != after.To {
+		diffs = append(diffs, fmt.Sprintf("Range %s - %s → %s - %s",
+			uic.FormatKey(before.From), uic.FormatKey(before.To), uic.FormatKey(after.From), uic.FormatKey(after.To)))
+	}
+	if before.Retired != after.Retired {
+		state := "Back in service"
+		if after.Retired {
+			state = "Retired"
+		}
+		diffs = append(diffs, fmt.Sprintf("%s: %s - %s (%s)", state, uic.FormatKey(after.From), uic.FormatKey(after.To), after.Spec.Type))
+	}
+	b, n := before.Spec, after.Spec
+	pairs := []struct {
+		label     string
+		old, next interface{}
+	}{
+		{"Type", b.Type, n.Type}, {"Axles", b.Axles, n.Axles}, {"RIV", b.RIVCode, n.RIVCode},
+		{"Empty weight", b.WeightEmpty, n.WeightEmpty}, {"Loaded weight", b.WeightLoaded, n.WeightLoaded},
+		{"Brake empty", b.BrakeWeightEmpty, n.BrakeWeightEmpty}, {"Brake loaded", b.BrakeWeightLoaded, n.BrakeWeightLoaded},
+		{"Length", b.Length, n.Length}, {"Max load", b.MaxCapacity, n.MaxCapacity},
+		{"Hand brake", b.HandBrakeWeight, n.HandBrakeWeight}, {"Triple valve", b.ControlValveType, n.ControlValveType},
+	}
+	for _, p := range pairs {
+		if p.old != p.next {
+			diffs = append(diffs, fmt.Sprintf("%s: %v → %v", p.label, p.old, p.next))
+		}
+	}
+	if before != after && len(diffs) == 0 {
+		diffs = append(diffs, "Other specification fields")
+	}
+	return strings.Join(diffs, "\n")
+}
+
+func intEntry(v int) *widget.Entry {
+	e := widget.NewEntry()
+	if v != 0 {
+		e.SetText(strconv.Itoa(v))
+	}
+	return e
+}
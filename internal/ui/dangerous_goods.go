@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"railguard/internal/core/domain"
+	"strings"
+)
+
+// lookupGoods finds a UN number in the dangerous goods list
+func (a *App) lookupGoods(un string) (*domain.DangerousGood, error) {
+	if a.GoodsRepo == nil {
+		return nil, errors.New("the dangerous goods list is not available")
+	}
+	return a.GoodsRepo.GetByUNNumber(un)
+}
+
+// goodsSummary describes a list entry for the wagon config form
+func goodsSummary(g *domain.DangerousGood) string {
+	lines := []string{
+		"UN" + g.UNNumber + " " + g.ProperName,
+		fmt.Sprintf("Class %s (%s) | Packing group %s | Hazard no. %s",
+			g.Class, orDash(g.ClassificationCode), orDash(g.PackingGroup), orDash(g.HazardNumber)),
+	}
+	if g.SpecialProvisions != "" {
+		lines = append(lines, "Special provisions: "+g.SpecialProvisions)
+	}
+	return strings.Join(lines, "\n")
+}
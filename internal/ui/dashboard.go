@@ -4,45 +4,52 @@ import (
 	"errors"
 	"fmt"
 	"image/color"
+	"io"
 	"railguard/internal/adapter/report"
 	"railguard/internal/adapter/storage/sqlite" // Import needed for HistoryItem
 	"railguard/internal/core/domain"
+	"railguard/internal/core/services"
+	"railguard/internal/core/uic"
+	"railguard/internal/core/units"
 	"strconv"
+	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 )
 
-// Decoder maps ported from Wagons.py logic
-var wagonTypeMap = map[string]string{
-	"1": "Covered", "2": "Open-Short", "3": "Open-High", "4": "Flat", "5": "Tank",
-	"6": "Rail Carrier", "7": "Fridge", "8": "Ballast", "9": "Bulk",
-}
-var axleMap = map[string]string{
-	"0": "3 Axles", "1": "2 Axles", "2": "4 Axles", "3": "4 Axles", "4": "4 Axles",
-	"8": "6 Axles", "9": "6 Axles",
-}
-
-// Analyzes wagon number digits in real-time
+// decodeWagonInfo describes a wagon number while it is typed.
+// National numbers are decoded digit by digit, UIC numbers once complete.
 func decodeWagonInfo(numStr string) string {
-	if len(numStr) == 0 {
+	digits := uic.Normalize(numStr)
+	if len(digits) == 0 {
 		return "..."
 	}
-	info := ""
-	if len(numStr) >= 1 {
-		if val, ok := wagonTypeMap[string(numStr[0])]; ok {
-			info += "Type: " + val + " | "
-		}
+	if _, _, err := uic.ParseEntry(digits); err != nil {
+		return "❌ " + err.Error()
 	}
-	if len(numStr) >= 3 {
-		if val, ok := axleMap[string(numStr[2])]; ok {
-			info += "Axles: " + val
+	if len(digits) > uic.NationalDigits {
+		if n, err := uic.Parse(digits); err == nil {
+			return n.Describe()
 		}
+		return fmt.Sprintf("UIC number: %d of %d digits", len(digits), uic.UICDigits)
+	}
+
+	info := ""
+	n := uic.DecodeNational(digits)
+	if n.Type != "" {
+		info += "Type: " + n.Type + " | "
+	}
+	if n.Axles > 0 {
+		info += fmt.Sprintf("Axles: %d", n.Axles)
 	}
 	return info
 }
@@ -77,28 +84,85 @@ func (a *App) makeDashboard() fyne.CanvasObject {
 	title.TextStyle = fyne.TextStyle{Bold: true}
 	slopeEntry := widget.NewEntry()
 	slopeEntry.SetText("10")
+	regimeSelect := widget.NewSelect([]string{domain.RegimeGoods.Label(), domain.RegimePassenger.Label()}, nil)
+	regimeSelect.SetSelected(a.CurrentRegime.Label())
+	brakingTab := newBrakingView()
+	routeLabel := widget.NewLabel(routeSummary(nil))
+	profileLabel := widget.NewLabel("None (single slope)")
+
+	// updateBraking redraws the braking curve and section speeds of the working train
+	updateBraking := func() {
+		if a.CurrentProfile == nil {
+			a.Composition.SetSlope(parseSlope(slopeEntry.Text))
+		}
+		if a.Composition.IsEmpty() {
+			brakingTab.Update(nil)
+			routeLabel.SetText(routeSummary(nil))
+			return
+		}
+		res, _, err := a.calculate()
+		if err != nil {
+			brakingTab.Update(nil)
+			routeLabel.SetText(routeSummary(nil))
+			return
+		}
+		brakingTab.Update(res.Braking)
+		routeLabel.SetText(routeSummary(res))
+	}
+	slopeEntry.OnChanged = func(string) { updateBraking() }
+
+	var clearProfileBtn *widget.Button
+	// showProfile switches between the slope entry and the loaded profile
+	showProfile := func() {
+		if a.CurrentProfile == nil {
+			profileLabel.SetText("None (single slope)")
+			slopeEntry.Enable()
+			clearProfileBtn.Disable()
+		} else {
+			p := a.CurrentProfile
+			profileLabel.SetText(fmt.Sprintf("%s (%.1f km)", p.Name, (p.Points[len(p.Points)-1].Chainage - p.Points[0].Chainage).Kilometres()))
+			slopeEntry.Disable()
+			clearProfileBtn.Enable()
+		}
+		updateBraking()
+	}
+	loadProfileBtn := widget.NewButtonWithIcon("Load", theme.FolderOpenIcon(), func() {
+		a.showLoadProfileDialog(showProfile)
+	})
+	clearProfileBtn = widget.NewButtonWithIcon("Clear", theme.CancelIcon(), func() {
+		a.CurrentProfile = nil
+		showProfile()
+	})
+	clearProfileBtn.Disable()
+	regimeSelect.OnChanged = func(s string) {
+		a.CurrentRegime = domain.RegimeGoods
+		if s == domain.RegimePassenger.Label() {
+			a.CurrentRegime = domain.RegimePassenger
+		}
+		updateBraking()
+	}
 
 	trainObjectsBox := container.NewHBox()
 	visualScroll := container.NewHScroll(trainObjectsBox)
 	visualScroll.SetMinSize(fyne.NewSize(0, 100))
 
-	// Define refreshVisuals first so we can use it in callbacks
-	var refreshVisuals func()
-	refreshVisuals = func() {
+	// refreshVisuals redraws the train strip from the composition
+	refreshVisuals := func() {
 		trainObjectsBox.Objects = nil
 
 		// 1. Render Locomotives
-		for i, l := range a.CurrentLocos {
+		for i, l := range a.Composition.Locomotives() {
 			currentLoco := l
 			idx := i
-			btn := widget.NewButton(fmt.Sprintf("🚂 %d", l.Number), func() { a.showLocoActions(currentLoco, idx, refreshVisuals) })
+			btn := widget.NewButton(fmt.Sprintf("🚂 %d", l.Number), func() { a.showLocoActions(currentLoco, idx) })
 			btn.Importance = widget.SuccessImportance // Green for Loco
 			trainObjectsBox.Add(btn)
 			trainObjectsBox.Add(container.NewCenter(&canvas.Line{StrokeColor: color.White, StrokeWidth: 2, Position2: fyne.NewPos(10, 0)}))
 		}
 
 		// 2. Render Wagons
-		for i, w := range a.CurrentTrain {
+		wagons := a.Composition.Wagons()
+		for i, w := range wagons {
 			currentWagon := w
 			idx := i
 			label := fmt.Sprintf("🚃 %d", w.WagonSpec.Number)
@@ -106,7 +170,7 @@ func (a *App) makeDashboard() fyne.CanvasObject {
 				label = "⚠️ " + strconv.Itoa(w.WagonSpec.Number)
 			}
 
-			btn := widget.NewButton(label, func() { a.openWagonEditForm(currentWagon, idx, refreshVisuals) })
+			btn := widget.NewButton(label, func() { a.openWagonEditForm(currentWagon, idx) })
 
 			// Visual status logic
 			if w.HasDangerousGoods {
@@ -119,22 +183,57 @@ func (a *App) makeDashboard() fyne.CanvasObject {
 				btn.Importance = widget.HighImportance // Blue (Loaded)
 			}
 			trainObjectsBox.Add(btn)
-			if i < len(a.CurrentTrain)-1 {
+			if i < len(wagons)-1 {
 				trainObjectsBox.Add(container.NewCenter(&canvas.Line{StrokeColor: color.White, StrokeWidth: 2, Position2: fyne.NewPos(10, 0)}))
 			}
 		}
 		trainObjectsBox.Refresh()
+		updateBraking()
+	}
+
+	// Undo and redo name the command they act on
+	undoBtn := widget.NewButtonWithIcon("Undo", theme.ContentUndoIcon(), func() { a.Composition.Undo() })
+	redoBtn := widget.NewButtonWithIcon("Redo", theme.ContentRedoIcon(), func() { a.Composition.Redo() })
+	updateUndo := func() {
+		if action, ok := a.Composition.CanUndo(); ok {
+			undoBtn.SetText("Undo " + action)
+			undoBtn.Enable()
+		} else {
+			undoBtn.SetText("Undo")
+			undoBtn.Disable()
+		}
+		if action, ok := a.Composition.CanRedo(); ok {
+			redoBtn.SetText("Redo " + action)
+			redoBtn.Enable()
+		} else {
+			redoBtn.SetText("Redo")
+			redoBtn.Disable()
+		}
 	}
+	updateUndo()
+	a.MainWindow.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) { a.Composition.Undo() })
+	a.MainWindow.Canvas().AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyY, Modifier: fyne.KeyModifierShortcutDefault}, func(fyne.Shortcut) { a.Composition.Redo() })
+
+	a.Composition.OnChange(func(e services.CompositionEvent) {
+		if e.Kind == services.ChangeSlope {
+			return // Follows the slope entry or the profile, nothing to redraw
+		}
+		if e.Kind == services.ChangeLoaded && a.CurrentProfile == nil {
+			slopeEntry.SetText(strconv.FormatFloat(a.Composition.Slope().Permil(), 'f', -1, 64))
+		}
+		refreshVisuals()
+		updateUndo()
+	})
 
 	// --- WAGON INPUT SECTION ---
 	wagonNumEntry := widget.NewEntry()
-	wagonNumEntry.SetPlaceHolder("6-digit Wagon Number")
+	wagonNumEntry.SetPlaceHolder("6-digit or 12-digit UIC Wagon Number")
 	searchFeedback := widget.NewLabel("...")
 	addWagonBtn := widget.NewButtonWithIcon("Add to Composition", theme.ContentAddIcon(), nil)
 	addWagonBtn.Disable()
 
 	infoBtn := widget.NewButtonWithIcon("Technical Specs", theme.InfoIcon(), func() {
-		num, _ := strconv.Atoi(wagonNumEntry.Text)
+		num, _, _ := uic.ParseEntry(wagonNumEntry.Text)
 		w, err := a.WagonRepo.GetWagonByNumber(num)
 		if err != nil {
 			a.ShowError(err)
@@ -142,7 +241,7 @@ func (a *App) makeDashboard() fyne.CanvasObject {
 		}
 
 		details := fmt.Sprintf(`[ FULL SPECIFICATIONS ]
-Wagon No: %d | Class: %s
+Wagon No: %s | Class: %s
 Manufacturer: %s (%s) | Axles: %d
 Length: %.2f m | RIV: %s
 --------------------------------------
@@ -159,22 +258,38 @@ Hand Brake: %s (%.1f t)
 Bogie: %s | Springs: %s
 Bearing: %s | Coupling: %s
 Wheel: %.0f mm | Pivot Dist: %.2f m`,
-			w.Number, w.Type, w.Manufacturer, w.Year, w.Axles, w.Length, w.RIVCode,
-			w.WeightEmpty, w.MaxCapacity, w.WeightLoaded, w.LoadVolume,
-			w.ControlValveType, w.BrakeCylinderType, w.BrakeWeightEmpty, w.BrakeWeightLoaded,
-			w.HandBrakeType, w.HandBrakeWeight,
+			uic.FormatNumber(w.Number), w.Type, w.Manufacturer, w.Year, w.Axles, w.Length.Metres(), w.RIVCode,
+			w.WeightEmpty.Tonnes(), w.MaxCapacity.Tonnes(), w.WeightLoaded.Tonnes(), w.LoadVolume,
+			w.ControlValveType, w.BrakeCylinderType, w.BrakeWeightEmpty.Tonnes(), w.BrakeWeightLoaded.Tonnes(),
+			w.HandBrakeType, w.HandBrakeWeight.Tonnes(),
 			w.BogieType, w.SpringType, w.BearingType, w.CouplingType,
-			w.WheelDiameter, w.BogiePivotDistance)
+			w.WheelDiameter.Millimetres(), w.BogiePivotDistance.Metres())
+
+		if lc, err := uic.DecodeLetters(w.RIVCode); err == nil {
+			details += "\n--------------------------------------\n[ RIV CODE " + w.RIVCode + " ]\n" + lc.Describe()
+		}
 
 		scroll := container.NewVScroll(widget.NewLabel(details))
 		scroll.SetMinSize(fyne.NewSize(450, 450))
 		dialog.ShowCustom("Wagon Technical Sheet", "Close", scroll, a.MainWindow)
 	})
 
+	wagonHistoryBtn := widget.NewButtonWithIcon("Wagon History", theme.HistoryIcon(), func() {
+		num, _, _ := uic.ParseEntry(wagonNumEntry.Text)
+		a.showWagonHistory(num)
+	})
+	wagonHistoryBtn.Disable()
+
 	wagonNumEntry.OnChanged = func(s string) {
 		searchFeedback.SetText(decodeWagonInfo(s))
-		if len(s) == 6 {
-			num, _ := strconv.Atoi(s)
+		num, complete, _ := uic.ParseEntry(s)
+		// A wagon may appear in history even if it is missing from the catalogue
+		if complete {
+			wagonHistoryBtn.Enable()
+		} else {
+			wagonHistoryBtn.Disable()
+		}
+		if complete {
 			if _, err := a.WagonRepo.GetWagonByNumber(num); err == nil {
 				addWagonBtn.Enable()
 				infoBtn.Enable()
@@ -189,11 +304,10 @@ Wheel: %.0f mm | Pivot Dist: %.2f m`,
 	}
 
 	addWagonBtn.OnTapped = func() {
-		num, _ := strconv.Atoi(wagonNumEntry.Text)
+		num, _, _ := uic.ParseEntry(wagonNumEntry.Text)
 		w, _ := a.WagonRepo.GetWagonByNumber(num)
-		a.openWagonEditForm(domain.SelectedWagon{WagonSpec: *w}, -1, refreshVisuals)
+		a.openWagonEditForm(domain.SelectedWagon{WagonSpec: *w}, -1)
 		wagonNumEntry.SetText("")
-		refreshVisuals()
 	}
 
 	// --- LOCOMOTIVE TAB LOGIC (FIXED) ---
@@ -216,17 +330,13 @@ Wheel: %.0f mm | Pivot Dist: %.2f m`,
 			if ok {
 				w, _ := strconv.ParseFloat(weightEntry.Text, 64)
 				n, _ := strconv.Atoi(numEntry.Text)
-				// Simple logic: if hot, brake weight is approx 80% (simplified)
-				br := 0.0
-				if hotCheck.Checked {
-					br = w * 0.8
+				newLoco, err := domain.NewLocomotive(idEntry.Text, n, units.Tonnes(w), hotCheck.Checked)
+				if err == nil {
+					err = a.Composition.AddLocomotive(newLoco)
 				}
-
-				newLoco := domain.Locomotive{
-					ID: idEntry.Text, Number: n, Weight: w, BrakeWeight: br, IsHot: hotCheck.Checked,
+				if err != nil {
+					a.ShowError(err)
 				}
-				a.CurrentLocos = append(a.CurrentLocos, newLoco)
-				refreshVisuals()
 			}
 		}, a.MainWindow)
 	})
@@ -241,101 +351,152 @@ Wheel: %.0f mm | Pivot Dist: %.2f m`,
 
 	// 1. Calculate
 	calcBtn := widget.NewButtonWithIcon("CALCULATE", theme.ConfirmIcon(), func() {
-		s, _ := strconv.Atoi(slopeEntry.Text)
-		a.CurrentSlope = s
-		isSafe, msg := a.Validator.ValidateComposition(a.CurrentTrain)
+		a.Composition.SetSlope(parseSlope(slopeEntry.Text))
+		isSafe, msg := a.Validator.ValidateComposition(a.Composition.Wagons())
 		if !isSafe {
 			dialog.ShowError(errors.New(msg), a.MainWindow)
 			return
 		}
 
-		res, train, _ := a.Calculator.CalculateTrainParameters(a.CurrentLocos, a.CurrentTrain, a.CurrentSlope)
+		res, train, err := a.calculate()
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
 		statusText := "✅ SAFETY PASSED"
 		if !res.IsSafe {
-			statusText = "❌ SAFETY FAILED"
+			statusText = "❌ SAFETY FAILED\n" + res.Message
+		}
+		// A train without weight gets no braking curve
+		stopText := "Stopping Distance: not available for this train"
+		if res.Braking != nil {
+			stopText = "Stopping Distance: train cannot stop on this gradient"
+			if d, ok := res.Braking.DistanceAt(res.MaxSpeed); ok {
+				stopText = fmt.Sprintf("Stopping Distance from %v: %.0f m", res.MaxSpeed, d.Metres())
+			}
+		}
+
+		if res.Profile != "" {
+			stopText += fmt.Sprintf("\nProfile %s: governing gradient %g ‰, see the Route Profile tab", res.Profile, a.Composition.Slope().Permil())
 		}
 
-		dialog.ShowInformation("Result", fmt.Sprintf("%s\nMax Speed: %d km/h\nWeight: %.1f t", statusText, res.MaxSpeed, train.TotalWeight), a.MainWindow)
+		details := strings.TrimSpace(tractionSummary(res.Traction) + "\n" + couplerSummary(res.Couplers) + "\n" + consistSummary(res.ConsistWarnings))
+		resultLabel := widget.NewLabel(fmt.Sprintf("%s\nMax Speed: %v\nWeight: %v\n%s\n%s", statusText, res.MaxSpeed, train.TotalWeight, stopText, details))
+		resultLabel.Wrapping = fyne.TextWrapWord
+		resultScroll := container.NewVScroll(resultLabel)
+		resultScroll.SetMinSize(fyne.NewSize(480, 360))
+		traceBtn := widget.NewButtonWithIcon("Show Calculation Trace", theme.ListIcon(), func() { a.showCalculationTrace(res) })
+		dialog.ShowCustom("Result", "OK", container.NewBorder(nil, traceBtn, nil, nil, resultScroll), a.MainWindow)
 	})
 	calcBtn.Importance = widget.HighImportance
 
 	// 2. Save
 	saveBtn := widget.NewButtonWithIcon("SAVE", theme.DocumentSaveIcon(), func() {
-		if len(a.CurrentTrain) == 0 {
+		if len(a.Composition.Wagons()) == 0 {
 			return
 		}
-		s, _ := strconv.Atoi(slopeEntry.Text)
-		a.CurrentSlope = s
-		res, train, _ := a.Calculator.CalculateTrainParameters(a.CurrentLocos, a.CurrentTrain, a.CurrentSlope)
-		a.showSaveDialog(train.TotalWeight, res.MaxSpeed)
+		a.Composition.SetSlope(parseSlope(slopeEntry.Text))
+		a.showSaveDialog()
 	})
 
 	// 3. History
 	historyBtn := widget.NewButtonWithIcon("HISTORY", theme.HistoryIcon(), func() {
-		a.showHistoryDialog(refreshVisuals)
+		a.showHistoryDialog()
+	})
+
+	// 4. Open License (restore a composition from a license PDF)
+	openLicenseBtn := widget.NewButtonWithIcon("OPEN LICENSE", theme.FolderOpenIcon(), func() {
+		a.showOpenLicenseDialog()
+	})
+
+	// 5. License Archive
+	archiveBtn := widget.NewButtonWithIcon("LICENSES", theme.StorageIcon(), func() {
+		a.showLicenseArchive()
 	})
 
-	// 4. PDF (RESTORED)
+	// 6. Defect Register
+	defectsBtn := widget.NewButtonWithIcon("DEFECTS", theme.WarningIcon(), func() {
+		a.showDefectRegister()
+	})
+
+	// 7. Wagon Catalogue
+	catalogueBtn := widget.NewButtonWithIcon("CATALOGUE", theme.ListIcon(), func() {
+		a.showWagonCatalogue()
+	})
+
+	// 8. PDF (RESTORED)
 	pdfBtn := widget.NewButtonWithIcon("PDF LICENSE", theme.FileIcon(), func() {
-		if len(a.CurrentTrain) == 0 {
+		if len(a.Composition.Wagons()) == 0 {
 			return
 		}
-		tn := widget.NewEntry()
-		dr := widget.NewEntry()
-		bs := widget.NewEntry()
-		org := widget.NewEntry()
-		dst := widget.NewEntry()
-
-		dialog.ShowForm("Generate Brake License", "Generate", "Cancel", []*widget.FormItem{
-			widget.NewFormItem("Train No:", tn), widget.NewFormItem("Driver:", dr),
-			widget.NewFormItem("Train Boss:", bs), widget.NewFormItem("Origin:", org), widget.NewFormItem("Dest:", dst),
-		}, func(ok bool) {
-			if ok {
-				info := domain.TripInfo{TrainNumber: tn.Text, DriverName: dr.Text, TrainBossName: bs.Text, Origin: org.Text, Destination: dst.Text}
-				s, _ := strconv.Atoi(slopeEntry.Text)
-				a.CurrentSlope = s
-				res, train, _ := a.Calculator.CalculateTrainParameters(a.CurrentLocos, a.CurrentTrain, a.CurrentSlope)
-
-				err := report.NewPDFGenerator().GenerateBrakeLicense(train, res, info)
-				if err == nil {
-					a.ShowInfo("Success", "PDF License Generated Successfully!")
-				} else {
-					a.ShowError(err)
-				}
+		a.showTripForm("Generate Brake License", "Generate", a.Composition.Trip(), func(info domain.TripInfo) {
+			// Remember the trip so the next form does not ask again
+			a.Composition.SetTrip(info)
+			a.Composition.SetSlope(parseSlope(slopeEntry.Text))
+			res, train, err := a.calculate()
+			if err != nil {
+				a.ShowError(err)
+				return
 			}
-		}, a.MainWindow)
+
+			a.issueLicense(train, res, info)
+		})
 	})
 
 	// Layout Assembly
 	wagonBox := container.NewVBox(
 		widget.NewLabel("Wagon Search:"),
 		wagonNumEntry,
-		container.NewHBox(infoBtn, addWagonBtn),
+		container.NewHBox(infoBtn, addWagonBtn, wagonHistoryBtn),
 		searchFeedback,
 	)
 
+	// Inverse query: how much braking is missing for a booked line speed
+	requiredSpeedEntry := widget.NewEntry()
+	requiredSpeedEntry.SetPlaceHolder("Booked line speed (km/h)")
+	requiredSpeedBtn := widget.NewButtonWithIcon("Check Requirement", theme.QuestionIcon(), func() {
+		speed, err := strconv.Atoi(strings.TrimSpace(requiredSpeedEntry.Text))
+		if err != nil || speed <= 0 {
+			a.ShowError(fmt.Errorf("invalid line speed %q", requiredSpeedEntry.Text))
+			return
+		}
+		updateBraking() // Brings the composition slope up to date
+		a.showBrakeRequirement(units.KmPerHour(float64(speed)))
+	})
+	requiredSpeedBox := container.NewBorder(nil, nil, nil, requiredSpeedBtn, requiredSpeedEntry)
+
 	tabs := container.NewAppTabs(
 		container.NewTabItem("Locomotives", locoTabContent),
 		container.NewTabItem("Wagons", wagonBox),
+		container.NewTabItem("Braking Curve", container.NewBorder(requiredSpeedBox, nil, nil, nil, brakingTab.Object())),
+		container.NewTabItem("Route Profile", container.NewVScroll(routeLabel)),
 	)
 
 	// Top Section
 	topSection := container.NewVBox(
 		title,
-		widget.NewForm(widget.NewFormItem("Track Slope (permil):", slopeEntry)),
+		widget.NewForm(
+			widget.NewFormItem("Track Slope (permil):", slopeEntry),
+			widget.NewFormItem("Gradient Profile:", container.NewBorder(nil, nil, nil, container.NewHBox(loadProfileBtn, clearProfileBtn), profileLabel)),
+			widget.NewFormItem("Brake Regime:", regimeSelect),
+		),
 	)
 
 	// Bottom Section (Buttons)
 	bottomSection := container.NewVBox(
 		widget.NewSeparator(),
 		a.makeLegend(),
-		visualScroll,
+		container.NewBorder(nil, nil, nil, container.NewHBox(undoBtn, redoBtn), visualScroll),
 		widget.NewSeparator(),
 		// Arrange buttons nicely
 		container.NewGridWithColumns(2,
 			historyBtn,
 			saveBtn,
 			pdfBtn,
+			openLicenseBtn,
+			archiveBtn,
+			defectsBtn,
+			catalogueBtn,
 			calcBtn, // دکمه محاسبه را پایین‌تر یا شاخص‌تر می‌گذاریم
 		),
 	)
@@ -345,51 +506,143 @@ Wheel: %.0f mm | Pivot Dist: %.2f m`,
 
 // --- NEW HELPER FUNCTIONS FOR SAVE & HISTORY ---
 
-func (a *App) showSaveDialog(currentWeight float64, maxSpeed int) {
-	tnEntry := widget.NewEntry()
-	tnEntry.SetPlaceHolder("e.g. 4055")
-	driverEntry := widget.NewEntry()
+func (a *App) showSaveDialog() {
+	repo, ok := a.WagonRepo.(*sqlite.WagonRepository)
+	if !ok {
+		return
+	}
 
-	dialog.ShowForm("Save Train Composition", "Save", "Cancel", []*widget.FormItem{
-		widget.NewFormItem("Train Number:", tnEntry),
-		widget.NewFormItem("Driver Name:", driverEntry),
-	}, func(confirm bool) {
-		if confirm {
-			item := sqlite.HistoryItem{
-				TrainNumber: tnEntry.Text,
-				DriverName:  driverEntry.Text,
-				Slope:       a.CurrentSlope,
-				TotalWeight: currentWeight,
-				MaxSpeed:    maxSpeed,
-				Locos:       a.CurrentLocos,
-				Wagons:      a.CurrentTrain,
-			}
+	a.showTripForm("Save Train Composition", "Save", a.Composition.Trip(), func(info domain.TripInfo) {
+		a.Composition.SetTrip(info)
+		res, train, err := a.calculate()
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		snapshot := a.Composition.Snapshot()
+		validation := a.Validator.CheckComposition(snapshot.Wagons)
 
-			if repo, ok := a.WagonRepo.(*sqlite.WagonRepository); ok {
-				err := repo.SaveTrainComposition(item)
-				if err == nil {
-					a.ShowInfo("Success", "Train Saved to History!")
-				} else {
-					a.ShowError(err)
-				}
+		item := sqlite.HistoryItem{
+			ID:          snapshot.HistoryID,
+			Trip:        info,
+			Slope:       snapshot.Slope,
+			TotalWeight: train.TotalWeight,
+			MaxSpeed:    res.MaxSpeed,
+			Locos:       snapshot.Locos,
+			Wagons:      snapshot.Wagons,
+			Result:      res,
+			Validation:  &validation,
+		}
+
+		saveAsNew := func() {
+			id, err := repo.SaveTrainComposition(item)
+			if err != nil {
+				a.ShowError(err)
+				return
 			}
+			a.Composition.SetHistoryID(id)
+			a.attachDefects(info, snapshot.Wagons)
+			a.ShowInfo("Success", "Train Saved to History!")
 		}
-	}, a.MainWindow)
+
+		// A train loaded from history can be overwritten or kept as a new entry
+		if snapshot.HistoryID == 0 {
+			saveAsNew()
+			return
+		}
+
+		var d dialog.Dialog
+		updateBtn := widget.NewButtonWithIcon(fmt.Sprintf("Update Existing #%d", item.ID), theme.DocumentSaveIcon(), func() {
+			d.Hide()
+			if err := repo.UpdateTrainComposition(item); err != nil {
+				a.ShowError(err)
+				return
+			}
+			a.attachDefects(info, snapshot.Wagons)
+			a.ShowInfo("Success", "History Entry Updated!")
+		})
+		updateBtn.Importance = widget.HighImportance
+		newBtn := widget.NewButtonWithIcon("Save as New", theme.ContentAddIcon(), func() {
+			d.Hide()
+			saveAsNew()
+		})
+		msg := widget.NewLabel(fmt.Sprintf("This train was loaded from history entry #%d.", item.ID))
+		d = dialog.NewCustom("Save Train Composition", "Cancel", container.NewVBox(msg, updateBtn, newBtn), a.MainWindow)
+		d.Show()
+	})
 }
 
-func (a *App) showHistoryDialog(loadCallback func()) {
+// historyPageSize is how many entries the history list fetches at a time
+const historyPageSize = 50
+
+var historySortOptions = map[string]struct {
+	column string
+	asc    bool
+}{
+	"Newest first":   {sqlite.SortByCreated, false},
+	"Oldest first":   {sqlite.SortByCreated, true},
+	"Train number":   {sqlite.SortByTrainNumber, true},
+	"Heaviest first": {sqlite.SortByWeight, false},
+	"Fastest first":  {sqlite.SortByMaxSpeed, false},
+}
+
+func (a *App) showHistoryDialog() {
 	repo, ok := a.WagonRepo.(*sqlite.WagonRepository)
 	if !ok {
 		return
 	}
 
-	history, err := repo.GetAllHistory()
-	if err != nil {
-		a.ShowError(err)
-		return
+	// --- Filters ---
+	trainEntry := widget.NewEntry()
+	trainEntry.SetPlaceHolder("Train No starts with")
+	driverEntry := widget.NewEntry()
+	driverEntry.SetPlaceHolder("Driver")
+	fromEntry := widget.NewEntry()
+	fromEntry.SetPlaceHolder("From (YYYY-MM-DD)")
+	toEntry := widget.NewEntry()
+	toEntry.SetPlaceHolder("To (YYYY-MM-DD)")
+	wagonEntry := widget.NewEntry()
+	wagonEntry.SetPlaceHolder("Contains Wagon No")
+	safeSelect := widget.NewSelect([]string{"All", "Safe", "Unsafe"}, nil)
+	safeSelect.SetSelected("All")
+	sortSelect := widget.NewSelect([]string{"Newest first", "Oldest first", "Train number", "Heaviest first", "Fastest first"}, nil)
+	sortSelect.SetSelected("Newest first")
+	status := widget.NewLabel("")
+
+	var (
+		query   sqlite.HistoryQuery
+		history []sqlite.HistoryItem
+		total   int
+		loading bool
+		gen     int // Bumped by every new search, so a late page of an old one is dropped
+	)
+
+	var list *widget.List
+	// loadMore queries the next page in the background and appends it on the UI thread
+	loadMore := func() {
+		loading = true
+		q, started := query, gen
+		q.Offset = len(history)
+		go func() {
+			page, err := repo.QueryHistory(q)
+			fyne.Do(func() {
+				if started != gen {
+					return
+				}
+				loading = false
+				if err != nil {
+					a.ShowError(err)
+					return
+				}
+				history = append(history, page.Items...)
+				total = page.Total
+				status.SetText(fmt.Sprintf("Showing %d of %d", len(history), total))
+				list.Refresh()
+			})
+		}()
 	}
 
-	list := widget.NewList(
+	list = widget.NewList(
 		func() int { return len(history) },
 		func() fyne.CanvasObject {
 			return container.NewVBox(
@@ -403,54 +656,243 @@ func (a *App) showHistoryDialog(loadCallback func()) {
 			lblTitle := box.Objects[0].(*widget.Label)
 			lblDetails := box.Objects[1].(*widget.Label)
 
-			dateStr := h.CreatedAt.Format("2006-01-02 15:04")
-			lblTitle.SetText(fmt.Sprintf("Train #%s | Driver: %s", h.TrainNumber, h.DriverName))
-			lblDetails.SetText(fmt.Sprintf("%s | Weight: %.0f t | Speed: %d km/h", dateStr, h.TotalWeight, h.MaxSpeed))
+			lblTitle.SetText(historyTitle(h))
+			lblDetails.SetText(historyDetails(h))
+
+			// Fetch the next page once the last row becomes visible
+			if i == len(history)-1 && len(history) < total && !loading {
+				loadMore()
+			}
 		},
 	)
 
+	search := func() {
+		q := sqlite.HistoryQuery{
+			Limit:             historyPageSize,
+			TrainNumberPrefix: strings.TrimSpace(trainEntry.Text),
+			DriverName:        strings.TrimSpace(driverEntry.Text),
+		}
+		sort := historySortOptions[sortSelect.Selected]
+		q.SortBy, q.Asc = sort.column, sort.asc
+
+		var err error
+		if s := strings.TrimSpace(fromEntry.Text); s != "" {
+			if q.From, err = time.Parse(dateLayout, s); err != nil {
+				a.ShowError(fmt.Errorf("invalid From date %q, use YYYY-MM-DD", s))
+				return
+			}
+		}
+		if s := strings.TrimSpace(toEntry.Text); s != "" {
+			if q.To, err = time.Parse(dateLayout, s); err != nil {
+				a.ShowError(fmt.Errorf("invalid To date %q, use YYYY-MM-DD", s))
+				return
+			}
+		}
+		if s := strings.TrimSpace(wagonEntry.Text); s != "" {
+			if q.WagonNumber, err = parseCatalogueNumber(s); err != nil {
+				a.ShowError(fmt.Errorf("invalid wagon number %q: %w", s, err))
+				return
+			}
+		}
+		switch safeSelect.Selected {
+		case "Safe":
+			safe := true
+			q.Safe = &safe
+		case "Unsafe":
+			safe := false
+			q.Safe = &safe
+		}
+
+		query = q
+		history = nil
+		total = 0
+		gen++
+		list.UnselectAll()
+		list.ScrollToTop()
+		loadMore()
+	}
+
 	list.OnSelected = func(id widget.ListItemID) {
-		selected := history[id]
-		dialog.ShowConfirm("Load Train?", fmt.Sprintf("Load Train #%s?\nCurrent unsaved changes will be lost.", selected.TrainNumber), func(b bool) {
+		list.Unselect(id)
+		// List rows carry no composition, fetch the full entry
+		selected, err := repo.GetHistory(history[id].ID)
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		a.showHistoryActions(repo, *selected, history, search)
+	}
+
+	searchBtn := widget.NewButtonWithIcon("Filter", theme.SearchIcon(), search)
+	searchBtn.Importance = widget.HighImportance
+
+	filters := container.NewVBox(
+		container.NewGridWithColumns(2, trainEntry, driverEntry, fromEntry, toEntry, wagonEntry, safeSelect, sortSelect, searchBtn),
+		status,
+	)
+
+	d := dialog.NewCustom("Saved Trains History", "Close", container.NewBorder(filters, nil, nil, nil, list), a.MainWindow)
+	d.Resize(fyne.NewSize(550, 650))
+	d.Show()
+	search()
+}
+
+// showHistoryActions offers load, edit, duplicate, compare and delete for one entry.
+func (a *App) showHistoryActions(repo *sqlite.WagonRepository, selected sqlite.HistoryItem, all []sqlite.HistoryItem, refreshList func()) {
+	var d dialog.Dialog
+
+	loadBtn := widget.NewButtonWithIcon("Load", theme.DownloadIcon(), func() {
+		dialog.ShowConfirm("Load Train?", fmt.Sprintf("Load Train #%s?\nCurrent unsaved changes will be lost.", selected.Trip.TrainNumber), func(b bool) {
 			if b {
-				a.CurrentLocos = selected.Locos
-				a.CurrentTrain = selected.Wagons
-				a.CurrentSlope = selected.Slope
-				loadCallback()
+				snapshot := domain.CompositionSnapshot{
+					Locos:     selected.Locos,
+					Wagons:    selected.Wagons,
+					Slope:     selected.Slope,
+					HistoryID: selected.ID,
+					Trip:      selected.Trip,
+				}
+				if err := a.Composition.Load(snapshot); err != nil {
+					a.ShowError(fmt.Errorf("the saved train cannot be loaded:\n%w", err))
+					return
+				}
+				d.Hide()
 			}
-			list.Unselect(id)
 		}, a.MainWindow)
-	}
+	})
+	loadBtn.Importance = widget.HighImportance
+
+	editBtn := widget.NewButtonWithIcon("Edit Trip Info", theme.DocumentCreateIcon(), func() {
+		a.showTripForm("Edit Trip Info", "Save", selected.Trip, func(info domain.TripInfo) {
+			if err := repo.UpdateHistoryTrip(selected.ID, info); err != nil {
+				a.ShowError(err)
+				return
+			}
+			if a.Composition.HistoryID() == selected.ID {
+				a.Composition.SetTrip(info)
+			}
+			d.Hide()
+			refreshList()
+		})
+	})
+
+	duplicateBtn := widget.NewButtonWithIcon("Duplicate as Template", theme.ContentCopyIcon(), func() {
+		if _, err := repo.DuplicateHistory(selected.ID); err != nil {
+			a.ShowError(err)
+			return
+		}
+		d.Hide()
+		refreshList()
+	})
+
+	compareBtn := widget.NewButtonWithIcon("Compare With...", theme.ViewRefreshIcon(), func() {
+		a.showCompareChooser(repo, selected, all)
+	})
+
+	deleteBtn := widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), func() {
+		dialog.ShowConfirm("Delete Entry?", fmt.Sprintf("Delete Train #%s from history?", selected.Trip.TrainNumber), func(b bool) {
+			if !b {
+				return
+			}
+			if err := repo.DeleteHistory(selected.ID); err != nil {
+				a.ShowError(err)
+				return
+			}
+			a.Composition.ForgetHistory(selected.ID)
+			d.Hide()
+			refreshList()
+		}, a.MainWindow)
+	})
+	deleteBtn.Importance = widget.DangerImportance
 
-	d := dialog.NewCustom("Saved Trains History", "Close", container.NewMax(list), a.MainWindow)
-	d.Resize(fyne.NewSize(500, 600))
+	info := widget.NewLabel(historyTitle(selected) + "\n" + historyDetails(selected))
+	content := container.NewVBox(info, widget.NewSeparator(), loadBtn, editBtn, duplicateBtn, compareBtn, widget.NewSeparator(), deleteBtn)
+	d = dialog.NewCustom(fmt.Sprintf("History Entry #%d", selected.ID), "Close", content, a.MainWindow)
 	d.Show()
 }
 
+func historyTitle(h sqlite.HistoryItem) string {
+	title := fmt.Sprintf("Train #%s | Driver: %s", h.Trip.TrainNumber, h.Trip.DriverName)
+	if h.Trip.Origin != "" || h.Trip.Destination != "" {
+		title += fmt.Sprintf(" | %s → %s", h.Trip.Origin, h.Trip.Destination)
+	}
+	return title
+}
+
+func historyDetails(h sqlite.HistoryItem) string {
+	details := fmt.Sprintf("%s | %d wagons | Weight: %.0f t | Speed: %.0f km/h", h.CreatedAt.Format("2006-01-02 15:04"), h.WagonCount, h.TotalWeight.Tonnes(), h.MaxSpeed.KmPerHour())
+	if h.Result != nil {
+		details += fmt.Sprintf(" | Brake: %d %%", h.Result.BrakePercentage)
+	}
+	if h.Validation != nil && !h.Validation.Passed {
+		details += fmt.Sprintf(" | ⚠️ %d conflict(s)", len(h.Validation.Findings))
+	}
+	return details
+}
+
+func (a *App) showOpenLicenseDialog() {
+	fd := dialog.NewFileOpen(func(rc fyne.URIReadCloser, err error) {
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		if rc == nil {
+			return // Cancelled
+		}
+		defer rc.Close()
+
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		license, err := report.ReadLicense(data)
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+
+		msg := fmt.Sprintf("Load Train #%s (%d wagons) from this license?\nCurrent unsaved changes will be lost.",
+			license.Trip.TrainNumber, len(license.Train.Wagons))
+		dialog.ShowConfirm("Load License?", msg, func(b bool) {
+			if b {
+				// The license does not record the slope, the current one is kept
+				snapshot := domain.CompositionSnapshot{
+					Locos:  license.Train.Locomotives,
+					Wagons: license.Train.Wagons,
+					Slope:  a.Composition.Slope(),
+					Trip:   license.Trip,
+				}
+				if err := a.Composition.Load(snapshot); err != nil {
+					a.ShowError(fmt.Errorf("the license holds an inconsistent train:\n%w", err))
+					return
+				}
+			}
+		}, a.MainWindow)
+	}, a.MainWindow)
+	fd.SetFilter(storage.NewExtensionFileFilter([]string{".pdf"}))
+	fd.Show()
+}
+
 // --- WAGON EDIT FORM ---
 
-func (a *App) openWagonEditForm(wagon domain.SelectedWagon, index int, refresh func()) {
+func (a *App) openWagonEditForm(wagon domain.SelectedWagon, index int) {
 	var d dialog.Dialog
 	checkDangerous := widget.NewCheck("Dangerous Goods", nil)
-	dangerCodes := []string{"1", "2b", "2a", "2at", "3a", "3bc", "4-1", "4-2", "4-3", "5-1", "5-2", "6-1", "6-1 HCN", "6-2", "7", "8", "9"}
-	dangerSelect := widget.NewSelect(dangerCodes, nil)
-	dangerSelect.Disable()
+	// Rebuilds the placard checklist, assigned once all cargo widgets exist
+	refreshPlacards := func() {}
+	cargoEdit := a.newCargoEditor(wagon.DangerousCargo(), func() { refreshPlacards() })
 
 	loadRadio := widget.NewRadioGroup([]string{"Empty", "Loaded"}, func(s string) {
 		if s == "Empty" {
 			checkDangerous.SetChecked(false)
 			checkDangerous.Disable()
-			dangerSelect.Disable()
 		} else {
 			checkDangerous.Enable()
 		}
 	})
 	checkDangerous.OnChanged = func(b bool) {
-		if b {
-			dangerSelect.Enable()
-		} else {
-			dangerSelect.Disable()
-		}
+		cargoEdit.SetEnabled(b)
+		refreshPlacards()
 	}
 
 	checkMainBrake := widget.NewCheck("Air Brake Healthy", nil)
@@ -469,94 +911,157 @@ func (a *App) openWagonEditForm(wagon domain.SelectedWagon, index int, refresh f
 			loadRadio.Selected = "Empty"
 		}
 		checkDangerous.Checked = wagon.HasDangerousGoods
-		dangerSelect.Selected = wagon.DangerousGoodsCode
+		cargoEdit.SetEnabled(wagon.HasDangerousGoods)
 		checkMainBrake.Checked = wagon.IsMainBrakeHealthy
 		checkHandBrake.Checked = wagon.IsHandBrakeHealthy
 		checkHandle.Checked = wagon.IsBrakeHandleHealthy
 	}
 
-	closeDialog := func() {
+	// cargo reads the dangerous goods inputs as they currently stand
+	cargo := func() domain.SelectedWagon {
+		w := wagon
+		w.SetCargo(nil)
+		if checkDangerous.Checked {
+			w.SetCargo(cargoEdit.Entries())
+		}
+		return w
+	}
+	placards := newPlacardChecklist(wagon.PlacardsConfirmed)
+	refreshPlacards = func() { placards.Update(cargo()) }
+	refreshPlacards()
+
+	// A wagon joining the composition starts with the defects still open in the register
+	defectLabel := widget.NewLabel("")
+	defectLabel.Hide()
+	if index == -1 {
+		if defects := a.openDefects(wagon.WagonSpec.Number); len(defects) > 0 {
+			for _, d := range defects {
+				switch d.Component {
+				case domain.DefectMainBrake:
+					checkMainBrake.Checked = false
+				case domain.DefectHandBrake:
+					checkHandBrake.Checked = false
+				case domain.DefectBrakeHandle:
+					checkHandle.Checked = false
+				}
+			}
+			defectLabel.SetText(defectWarning(defects))
+			defectLabel.Importance = widget.DangerImportance
+			defectLabel.Show()
+		}
+	}
+
+	// run sends a command to the composition and closes the form once it is accepted
+	run := func(err error) bool {
+		if err != nil {
+			a.ShowError(err)
+			return false
+		}
 		if d != nil {
 			d.Hide()
 		}
+		return true
 	}
 	var extraButtons []fyne.CanvasObject
 	if index >= 0 {
 		btnDelete := widget.NewButtonWithIcon("Remove", theme.DeleteIcon(), func() {
-			a.CurrentTrain = append(a.CurrentTrain[:index], a.CurrentTrain[index+1:]...)
-			refresh()
-			closeDialog()
+			run(a.Composition.RemoveWagon(index))
 		})
 		btnDelete.Importance = widget.DangerImportance
 		btnLeft := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
 			if index > 0 {
-				a.CurrentTrain[index], a.CurrentTrain[index-1] = a.CurrentTrain[index-1], a.CurrentTrain[index]
-				refresh()
-				closeDialog()
+				run(a.Composition.MoveWagon(index, index-1))
 			}
 		})
 		btnRight := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
-			if index < len(a.CurrentTrain)-1 {
-				a.CurrentTrain[index], a.CurrentTrain[index+1] = a.CurrentTrain[index+1], a.CurrentTrain[index]
-				refresh()
-				closeDialog()
+			if index < len(a.Composition.Wagons())-1 {
+				run(a.Composition.MoveWagon(index, index+1))
 			}
 		})
 		extraButtons = append(extraButtons, btnLeft, btnRight, layout.NewSpacer(), btnDelete)
 	}
 
 	saveBtn := widget.NewButtonWithIcon("Apply Changes", theme.DocumentSaveIcon(), func() {
-		updated := wagon
-		updated.IsLoaded = loadRadio.Selected == "Loaded"
-		updated.IsMainBrakeHealthy = checkMainBrake.Checked
-		updated.IsHandBrakeHealthy = checkHandBrake.Checked
-		updated.IsBrakeHandleHealthy = checkHandle.Checked
-		updated.HasDangerousGoods = checkDangerous.Checked
-		updated.DangerousGoodsCode = dangerSelect.Selected
-
-		if updated.IsLoaded {
-			updated.EffectiveWeight = wagon.WagonSpec.WeightLoaded
-			updated.EffectiveBrakeWeight = wagon.WagonSpec.BrakeWeightLoaded
-		} else {
-			updated.EffectiveWeight = wagon.WagonSpec.WeightEmpty
-			updated.EffectiveBrakeWeight = wagon.WagonSpec.BrakeWeightEmpty
+		state := domain.WagonState{
+			IsLoaded:             loadRadio.Selected == "Loaded",
+			IsMainBrakeHealthy:   checkMainBrake.Checked,
+			IsHandBrakeHealthy:   checkHandBrake.Checked,
+			IsBrakeHandleHealthy: checkHandle.Checked,
 		}
-		if !updated.IsMainBrakeHealthy {
-			updated.EffectiveBrakeWeight = 0
+		if checkDangerous.Checked {
+			if len(cargoEdit.Entries()) == 0 {
+				a.ShowError(errors.New("add at least one dangerous goods entry, or untick Dangerous Goods"))
+				return
+			}
+			state.Cargo = cargoEdit.Entries()
 		}
+		updated, err := domain.NewSelectedWagon(wagon.WagonSpec, state)
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		updated.PlacardsConfirmed = placards.Confirmed(updated)
 
 		if index == -1 {
-			a.CurrentTrain = append(a.CurrentTrain, updated)
+			err = a.Composition.AddWagon(updated)
 		} else {
-			a.CurrentTrain[index] = updated
+			err = a.Composition.ReplaceWagon(index, updated)
+		}
+		if !run(err) {
+			return
+		}
+
+		// Report only what changed, so reopening the form does not inflate the register
+		reported := updated.Defects()
+		if index >= 0 {
+			reported = newDefects(wagon.Defects(), reported)
 		}
-		refresh()
-		closeDialog()
+		a.reportDefects(updated.WagonSpec.Number, reported)
 	})
 
 	form := widget.NewForm(
 		widget.NewFormItem("Load Status:", loadRadio),
 		widget.NewFormItem("Braking Systems:", container.NewVBox(checkMainBrake, checkHandBrake, checkHandle)),
-		widget.NewFormItem("Cargo Type:", container.NewVBox(checkDangerous, dangerSelect)),
+		widget.NewFormItem("Cargo Type:", container.NewVBox(checkDangerous, cargoEdit.Object())),
 	)
+	form.Append("", placards.box)
 
-	content := container.NewVBox(form, widget.NewSeparator(), container.NewHBox(extraButtons...), widget.NewSeparator(), saveBtn)
+	content := container.NewBorder(nil, container.NewVBox(widget.NewSeparator(), container.NewHBox(extraButtons...), widget.NewSeparator(), saveBtn),
+		nil, nil, container.NewVScroll(container.NewVBox(defectLabel, form)))
 	d = dialog.NewCustom("Wagon Config #"+strconv.Itoa(wagon.WagonSpec.Number), "Cancel", content, a.MainWindow)
-	d.Resize(fyne.NewSize(400, 550))
+	d.Resize(fyne.NewSize(400, 650))
 	d.Show()
 }
 
-func (a *App) showLocoActions(loco domain.Locomotive, index int, refresh func()) {
+func (a *App) showLocoActions(loco domain.Locomotive, index int) {
 	var d dialog.Dialog
-	btnDelete := widget.NewButtonWithIcon("Remove Locomotive", theme.DeleteIcon(), func() {
-		a.CurrentLocos = append(a.CurrentLocos[:index], a.CurrentLocos[index+1:]...)
-		refresh()
+	run := func(err error) {
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
 		if d != nil {
 			d.Hide()
 		}
+	}
+	btnDelete := widget.NewButtonWithIcon("Remove Locomotive", theme.DeleteIcon(), func() {
+		run(a.Composition.RemoveLocomotive(index))
 	})
 	btnDelete.Importance = widget.DangerImportance
-	info := widget.NewLabel(fmt.Sprintf("Locomotive #%d\nModel: %s\nWeight: %.1f t", loco.Number, loco.ID, loco.Weight))
-	d = dialog.NewCustom("Locomotive Options", "Close", container.NewVBox(info, widget.NewSeparator(), btnDelete), a.MainWindow)
+	toggleText := "Haul Dead"
+	if !loco.IsHot {
+		toggleText = "Set Active (Hot)"
+	}
+	btnToggle := widget.NewButtonWithIcon(toggleText, theme.ViewRefreshIcon(), func() {
+		run(a.Composition.ToggleLocomotiveHot(index))
+	})
+	info := widget.NewLabel(fmt.Sprintf("Locomotive #%d\nModel: %s\nWeight: %v\nBrake Weight: %v", loco.Number, loco.ID, loco.Weight, loco.BrakeWeight))
+	d = dialog.NewCustom("Locomotive Options", "Close", container.NewVBox(info, widget.NewSeparator(), btnToggle, btnDelete), a.MainWindow)
 	d.Show()
 }
+
+// parseSlope reads the track slope entry in permil; anything unreadable counts as level
+func parseSlope(text string) units.Gradient {
+	p, _ := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	return units.Permil(p)
+}
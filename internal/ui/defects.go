@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"encoding/csv"
+	"fmt"
+	"railguard/internal/core/domain"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// openDefects returns the open defects of a wagon, or nil if the register is unavailable.
+func (a *App) openDefects(wagonNumber int) []domain.Defect {
+	if a.DefectRepo == nil {
+		return nil
+	}
+	defects, err := a.DefectRepo.OpenDefects(wagonNumber)
+	if err != nil {
+		a.ShowError(err)
+		return nil
+	}
+	return defects
+}
+
+// reportDefects opens or refreshes the defects of the components marked
+// unhealthy in the wagon form. The train number is not known yet; attachDefects
+// fills it in once the train is saved or licensed.
+func (a *App) reportDefects(wagonNumber int, components []domain.DefectComponent) {
+	if a.DefectRepo == nil || len(components) == 0 {
+		return
+	}
+	if err := a.DefectRepo.ReportDefects(wagonNumber, components, ""); err != nil {
+		a.ShowError(err)
+	}
+}
+
+// attachDefects records the train of trip on the open defects of its wagons
+// that were reported without one.
+func (a *App) attachDefects(trip domain.TripInfo, wagons []domain.SelectedWagon) {
+	if a.DefectRepo == nil {
+		return
+	}
+	numbers := make([]int, len(wagons))
+	for i, w := range wagons {
+		numbers[i] = w.WagonSpec.Number
+	}
+	if err := a.DefectRepo.AttachTrain(numbers, trip.TrainNumber); err != nil {
+		a.ShowError(err)
+	}
+}
+
+// newDefects returns the components in after that were not already in before
+func newDefects(before, after []domain.DefectComponent) []domain.DefectComponent {
+	var list []domain.DefectComponent
+	for _, c := range after {
+		known := false
+		for _, b := range before {
+			known = known || b == c
+		}
+		if !known {
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// defectWarning summarises open defects for the wagon config form
+func defectWarning(defects []domain.Defect) string {
+	lines := []string{"⚠️ OPEN DEFECTS - confirm with the examiner:"}
+	for _, d := range defects {
+		lines = append(lines, fmt.Sprintf("• %s since %s (reported %dx)", d.Component.Label(), d.OpenedAt.Format(dateLayout), d.ReportCount))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (a *App) showDefectRegister() {
+	if a.DefectRepo == nil {
+		return
+	}
+
+	var defects []domain.Defect
+	status := widget.NewLabel("")
+	openOnly := widget.NewCheck("Open defects only", nil)
+	openOnly.Checked = true
+
+	list := widget.NewList(
+		func() int { return len(defects) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("Wagon", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel("Details"),
+			)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			d := defects[i]
+			box := o.(*fyne.Container)
+			state := "🔧 OPEN"
+			if !d.IsOpen() {
+				state = "✅ CLOSED " + d.ClosedAt.Format(dateLayout)
+			}
+			box.Objects[0].(*widget.Label).SetText(fmt.Sprintf("%s | Wagon #%d | %s", state, d.WagonNumber, d.Component.Label()))
+			details := fmt.Sprintf("Opened %s | Last seen %s in train #%s | Reported %dx",
+				d.OpenedAt.Format(dateLayout), d.LastSeenAt.Format(dateLayout), orDash(d.TrainNumber), d.ReportCount)
+			if d.CloseNote != "" {
+				details += "\nWorkshop: " + d.CloseNote
+			}
+			box.Objects[1].(*widget.Label).SetText(details)
+		},
+	)
+
+	load := func() {
+		var err error
+		defects, err = a.DefectRepo.ListDefects(openOnly.Checked)
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		status.SetText(fmt.Sprintf("%d defect(s)", len(defects)))
+		list.Refresh()
+	}
+	openOnly.OnChanged = func(bool) { load() }
+
+	list.OnSelected = func(id widget.ListItemID) {
+		d := defects[id]
+		list.Unselect(id)
+		if !d.IsOpen() {
+			return
+		}
+		noteEntry := widget.NewMultiLineEntry()
+		noteEntry.SetPlaceHolder("Repair performed, workshop, reference...")
+		dialog.ShowForm(fmt.Sprintf("Close Defect: Wagon #%d %s", d.WagonNumber, d.Component.Label()), "Close Defect", "Cancel",
+			[]*widget.FormItem{widget.NewFormItem("Workshop Note:", noteEntry)}, func(ok bool) {
+				if !ok {
+					return
+				}
+				note := strings.TrimSpace(noteEntry.Text)
+				if note == "" {
+					a.ShowInfo("Close Defect", "A workshop note is required to close a defect.")
+					return
+				}
+				if err := a.DefectRepo.CloseDefect(d.ID, note); err != nil {
+					a.ShowError(err)
+					return
+				}
+				load()
+			}, a.MainWindow)
+	}
+
+	exportBtn := widget.NewButtonWithIcon("Export CSV", theme.DownloadIcon(), func() {
+		a.exportDefects(defects)
+	})
+
+	top := container.NewVBox(container.NewHBox(openOnly, exportBtn), status)
+	d := dialog.NewCustom("Defect Register", "Close", container.NewBorder(top, nil, nil, nil, list), a.MainWindow)
+	d.Resize(fyne.NewSize(600, 650))
+	d.Show()
+	load()
+}
+
+// exportDefects writes the listed defects as CSV for the maintenance depot
+func (a *App) exportDefects(defects []domain.Defect) {
+	if len(defects) == 0 {
+		a.ShowInfo("Export", "There are no defects to export.")
+		return
+	}
+	name := fmt.Sprintf("DefectRegister_%s.csv", time.Now().Format("20060102"))
+	a.saveFile(name, func(w fyne.URIWriteCloser) (string, error) {
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"ID", "Wagon", "Component", "Status", "Opened", "Last Seen", "Train", "Reports", "Closed", "Workshop Note"})
+		for _, d := range defects {
+			state, closed := "OPEN", ""
+			if !d.IsOpen() {
+				state, closed = "CLOSED", d.ClosedAt.Format("2006-01-02 15:04")
+			}
+			cw.Write([]string{
+				strconv.Itoa(d.ID), strconv.Itoa(d.WagonNumber), d.Component.Label(), state,
+				d.OpenedAt.Format("2006-01-02 15:04"), d.LastSeenAt.Format("2006-01-02 15:04"),
+				d.TrainNumber, strconv.Itoa(d.ReportCount), closed, d.CloseNote,
+			})
+		}
+		cw.Flush()
+		err := cw.Error()
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d defect(s) exported to %s", len(defects), w.URI().Name()), nil
+	})
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"railguard/internal/adapter/storage/sqlite"
+	"railguard/internal/core/domain"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const currentCompositionLabel = "Current composition (unsaved)"
+
+// showCompareChooser picks the second composition to compare a history entry with.
+// Candidates are the loaded history rows; the chosen entry is fetched in full.
+func (a *App) showCompareChooser(repo *sqlite.WagonRepository, base sqlite.HistoryItem, candidates []sqlite.HistoryItem) {
+	options := []string{currentCompositionLabel}
+	byLabel := make(map[string]sqlite.HistoryItem)
+	for _, h := range candidates {
+		if h.ID == base.ID {
+			continue
+		}
+		label := fmt.Sprintf("#%d Train %s (%s)", h.ID, h.Trip.TrainNumber, h.CreatedAt.Format("2006-01-02 15:04"))
+		options = append(options, label)
+		byLabel[label] = h
+	}
+
+	sel := widget.NewSelect(options, nil)
+	sel.SetSelectedIndex(0)
+
+	dialog.ShowCustomConfirm("Compare With", "Compare", "Cancel", sel, func(ok bool) {
+		if !ok {
+			return
+		}
+		before := domain.CompositionSnapshot{Locos: base.Locos, Wagons: base.Wagons, Slope: base.Slope}
+		beforeLabel := fmt.Sprintf("#%d Train %s", base.ID, base.Trip.TrainNumber)
+
+		after := a.Composition.Snapshot()
+		afterLabel := "Current composition"
+		if row, ok := byLabel[sel.Selected]; ok {
+			h, err := repo.GetHistory(row.ID)
+			if err != nil {
+				a.ShowError(err)
+				return
+			}
+			after = domain.CompositionSnapshot{Locos: h.Locos, Wagons: h.Wagons, Slope: h.Slope}
+			afterLabel = fmt.Sprintf("#%d Train %s", h.ID, h.Trip.TrainNumber)
+		}
+		a.showCompareDialog(beforeLabel, before, afterLabel, after)
+	}, a.MainWindow)
+}
+
+// showCompareDialog lists the differences between two compositions.
+func (a *App) showCompareDialog(beforeLabel string, before domain.CompositionSnapshot, afterLabel string, after domain.CompositionSnapshot) {
+	diff, err := a.Calculator.CompareCompositions(before, after)
+	if err != nil {
+		a.ShowError(err)
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[ %s ]  →  [ %s ]\n", beforeLabel, afterLabel)
+	b.WriteString("--------------------------------------\n")
+	fmt.Fprintf(&b, "Brake: %d %% → %d %% (%+d)\n", diff.OldResult.BrakePercentage, diff.NewResult.BrakePercentage, diff.BrakePercentageDelta)
+	fmt.Fprintf(&b, "Max Speed: %.0f → %.0f km/h (%+.0f)\n", diff.OldResult.MaxSpeed.KmPerHour(), diff.NewResult.MaxSpeed.KmPerHour(), diff.MaxSpeedDelta.KmPerHour())
+	fmt.Fprintf(&b, "Weight: %+.1f t | Brake Weight: %+.1f t\n", diff.WeightDelta.Tonnes(), diff.BrakeWeightDelta.Tonnes())
+
+	if diff.IsEmpty() {
+		b.WriteString("--------------------------------------\nWagons are identical.")
+	}
+	section := func(title string, changes []domain.WagonChange, line func(c domain.WagonChange) string) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "--------------------------------------\n[ %s: %d ]\n", title, len(changes))
+		for _, c := range changes {
+			b.WriteString(line(c) + "\n")
+		}
+	}
+	section("ADDED", diff.Added, func(c domain.WagonChange) string {
+		return fmt.Sprintf("+ %d at position %d", c.Number, c.NewPos)
+	})
+	section("REMOVED", diff.Removed, func(c domain.WagonChange) string {
+		return fmt.Sprintf("- %d from position %d", c.Number, c.OldPos)
+	})
+	section("REORDERED", diff.Moved, func(c domain.WagonChange) string {
+		return fmt.Sprintf("↔ %d: position %d → %d", c.Number, c.OldPos, c.NewPos)
+	})
+	section("CHANGED", diff.Changed, func(c domain.WagonChange) string {
+		return fmt.Sprintf("* %d: %s", c.Number, strings.Join(c.Details, ", "))
+	})
+
+	scroll := container.NewVScroll(widget.NewLabel(b.String()))
+	scroll.SetMinSize(fyne.NewSize(450, 450))
+	dialog.ShowCustom("Composition Comparison", "Close", scroll, a.MainWindow)
+}
@@ -0,0 +1,143 @@
+package ui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"railguard/internal/adapter/report"
+	"railguard/internal/core/domain"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// issueLicense writes a new license PDF and archives it in the database.
+func (a *App) issueLicense(train *domain.Train, res *domain.CalculationResult, info domain.TripInfo) {
+	// One issue time for the printed and signed date, the file name and the archive day
+	issuedAt := time.Now()
+	seq, err := a.LicenseRepo.NextSequence(info.TrainNumber, issuedAt)
+	if err != nil {
+		a.ShowError(err)
+		return
+	}
+	rec := domain.LicenseRecord{
+		Trip:     info,
+		Sequence: seq,
+		FileName: report.LicenseFileName(info.TrainNumber, issuedAt, seq),
+		IssuedAt: issuedAt,
+		Result:   *res,
+	}
+	for _, w := range train.Wagons {
+		rec.WagonNumbers = append(rec.WagonNumbers, w.WagonSpec.Number)
+	}
+
+	a.saveFile(rec.FileName, func(w fyne.URIWriteCloser) (string, error) {
+		hash := sha256.New()
+		payload, envelope, err := report.NewPDFGenerator(a.Signer).GenerateBrakeLicense(io.MultiWriter(w, hash), train, res, info, issuedAt)
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", err
+		}
+
+		// The user may have renamed the file in the save dialog
+		rec.FileName = w.URI().Name()
+		rec.Location = w.URI().String()
+		rec.FileHash = hex.EncodeToString(hash.Sum(nil))
+		rec.Payload = payload
+		rec.Signature = envelope
+		rec.ID, err = a.LicenseRepo.SaveLicense(rec)
+		if err != nil {
+			return "", err
+		}
+		a.attachDefects(info, train.Wagons)
+		return fmt.Sprintf("License #%d saved as %s", rec.ID, rec.FileName), nil
+	})
+}
+
+// reprintLicense writes an exact copy of an archived license, marked as DUPLICATE,
+// carrying the signature of the original.
+func (a *App) reprintLicense(rec domain.LicenseRecord) {
+	if len(rec.Payload) == 0 {
+		a.ShowInfo("Reprint", "This license was issued before archiving was available and cannot be reprinted.")
+		return
+	}
+	name := fmt.Sprintf("DUPLICATE_%s", rec.FileName)
+	a.saveFile(name, func(w fyne.URIWriteCloser) (string, error) {
+		err := report.NewPDFGenerator(a.Signer).ReprintLicense(w, rec.Payload, rec.Signature)
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", err
+		}
+		return "Duplicate saved as " + w.URI().Name(), nil
+	})
+}
+
+// saveFile picks where an exported file goes and passes the open writer to write.
+// Desktop: native save dialog. Mobile: app storage, then hand the file to the system.
+func (a *App) saveFile(fileName string, write func(w fyne.URIWriteCloser) (string, error)) {
+	if fyne.CurrentDevice().IsMobile() {
+		w, err := a.FyneApp.Storage().Create(fileName)
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		msg, err := write(w)
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		a.showFileShare(w.URI(), msg)
+		return
+	}
+
+	fd := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		if w == nil {
+			return // Cancelled
+		}
+		msg, err := write(w)
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		a.ShowInfo("Success", msg)
+	}, a.MainWindow)
+	fd.SetFileName(fileName)
+	fd.Show()
+}
+
+// showFileShare lets the user pass a file stored in app storage to another app.
+// Fyne has no native share sheet, so the file is opened with the system handler,
+// which offers sharing on Android.
+func (a *App) showFileShare(uri fyne.URI, msg string) {
+	var d dialog.Dialog
+	shareBtn := widget.NewButtonWithIcon("Open / Share", theme.MailSendIcon(), func() {
+		u, err := url.Parse(uri.String())
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		if err := a.FyneApp.OpenURL(u); err != nil {
+			a.ShowError(err)
+			return
+		}
+		d.Hide()
+	})
+	shareBtn.Importance = widget.HighImportance
+
+	d = dialog.NewCustom("File Saved", "Close", container.NewVBox(widget.NewLabel(msg), shareBtn), a.MainWindow)
+	d.Show()
+}
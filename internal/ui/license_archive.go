@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"railguard/internal/core/domain"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const dateLayout = "2006-01-02"
+
+func (a *App) showLicenseArchive() {
+	trainEntry := widget.NewEntry()
+	trainEntry.SetPlaceHolder("Train No")
+	driverEntry := widget.NewEntry()
+	driverEntry.SetPlaceHolder("Driver")
+	fromEntry := widget.NewEntry()
+	fromEntry.SetPlaceHolder("From (YYYY-MM-DD)")
+	toEntry := widget.NewEntry()
+	toEntry.SetPlaceHolder("To (YYYY-MM-DD)")
+	wagonEntry := widget.NewEntry()
+	wagonEntry.SetPlaceHolder("Contains Wagon No")
+
+	var results []domain.LicenseRecord
+	status := widget.NewLabel("")
+
+	list := widget.NewList(
+		func() int { return len(results) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("Train No", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel("Details"),
+			)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			rec := results[i]
+			box := o.(*fyne.Container)
+			lblTitle := box.Objects[0].(*widget.Label)
+			lblDetails := box.Objects[1].(*widget.Label)
+
+			verdict := "✅"
+			if !rec.Result.IsSafe {
+				verdict = "❌"
+			}
+			lblTitle.SetText(fmt.Sprintf("%s Train #%s | #%d of the day | Driver: %s", verdict, rec.Trip.TrainNumber, rec.Sequence, rec.Trip.DriverName))
+			lblDetails.SetText(fmt.Sprintf("%s | %d wagons | Brake %d %% | %.0f km/h | Rules %s",
+				rec.IssuedAt.Format("2006-01-02 15:04"), len(rec.WagonNumbers), rec.Result.BrakePercentage, rec.Result.MaxSpeed.KmPerHour(), rec.Result.RuleVersion))
+		},
+	)
+
+	search := func() {
+		var f domain.LicenseFilter
+		f.TrainNumber = strings.TrimSpace(trainEntry.Text)
+		f.DriverName = strings.TrimSpace(driverEntry.Text)
+
+		var err error
+		if s := strings.TrimSpace(fromEntry.Text); s != "" {
+			if f.From, err = time.Parse(dateLayout, s); err != nil {
+				a.ShowError(fmt.Errorf("invalid From date %q, use YYYY-MM-DD", s))
+				return
+			}
+		}
+		if s := strings.TrimSpace(toEntry.Text); s != "" {
+			if f.To, err = time.Parse(dateLayout, s); err != nil {
+				a.ShowError(fmt.Errorf("invalid To date %q, use YYYY-MM-DD", s))
+				return
+			}
+		}
+		if s := strings.TrimSpace(wagonEntry.Text); s != "" {
+			if f.WagonNumber, err = strconv.Atoi(s); err != nil {
+				a.ShowError(fmt.Errorf("invalid wagon number %q", s))
+				return
+			}
+		}
+
+		results, err = a.LicenseRepo.SearchLicenses(f)
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		status.SetText(fmt.Sprintf("%d license(s) found", len(results)))
+		list.Refresh()
+	}
+
+	list.OnSelected = func(id widget.ListItemID) {
+		rec := results[id]
+		msg := fmt.Sprintf("Train #%s, %s -> %s\nIssued %s\nFile: %s\nSHA-256: %s",
+			rec.Trip.TrainNumber, rec.Trip.Origin, rec.Trip.Destination,
+			rec.IssuedAt.Format("2006-01-02 15:04"), rec.FileName, rec.FileHash)
+		dialog.ShowCustomConfirm("Archived License", "Reprint (DUPLICATE)", "Close", widget.NewLabel(msg), func(b bool) {
+			if b {
+				a.reprintLicense(rec)
+			}
+			list.Unselect(id)
+		}, a.MainWindow)
+	}
+
+	searchBtn := widget.NewButtonWithIcon("Search", theme.SearchIcon(), search)
+	searchBtn.Importance = widget.HighImportance
+
+	filters := container.NewVBox(
+		container.NewGridWithColumns(2, trainEntry, driverEntry, fromEntry, toEntry, wagonEntry, searchBtn),
+		status,
+	)
+
+	d := dialog.NewCustom("License Archive", "Close", container.NewBorder(filters, nil, nil, nil, list), a.MainWindow)
+	d.Resize(fyne.NewSize(600, 650))
+	d.Show()
+	search()
+}
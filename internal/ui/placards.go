@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"railguard/internal/core/domain"
+	"railguard/internal/core/services"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// placardChecklist shows the placard items a wagon needs, for the examiner to confirm.
+// Confirmations survive rebuilding the list while the cargo is being edited.
+type placardChecklist struct {
+	box       *fyne.Container
+	confirmed map[string]bool
+}
+
+func newPlacardChecklist(confirmed []string) *placardChecklist {
+	c := &placardChecklist{box: container.NewVBox(), confirmed: make(map[string]bool)}
+	for _, key := range confirmed {
+		c.confirmed[key] = true
+	}
+	return c
+}
+
+// Update rebuilds the checklist for the wagon's current cargo
+func (c *placardChecklist) Update(w domain.SelectedWagon) {
+	c.box.RemoveAll()
+	items := services.RequiredPlacards(w)
+	if len(items) == 0 {
+		c.box.Refresh()
+		return
+	}
+	c.box.Add(widget.NewLabelWithStyle("Placard Checklist (confirm what is fitted):", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	for _, item := range items {
+		key := item.Key
+		check := widget.NewCheck(item.Text, func(b bool) { c.confirmed[key] = b })
+		check.Checked = c.confirmed[key]
+		c.box.Add(check)
+	}
+	c.box.Refresh()
+}
+
+// Confirmed returns the confirmed keys that the wagon still requires
+func (c *placardChecklist) Confirmed(w domain.SelectedWagon) []string {
+	var keys []string
+	for _, item := range services.RequiredPlacards(w) {
+		if c.confirmed[item.Key] {
+			keys = append(keys, item.Key)
+		}
+	}
+	return keys
+}
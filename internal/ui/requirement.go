@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"fmt"
+	"railguard/internal/core/units"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showBrakeRequirement tells how much braking the working train lacks for a booked line speed
+func (a *App) showBrakeRequirement(speed units.Speed) {
+	req, err := a.Calculator.RequiredBraking(a.Composition.Locomotives(), a.Composition.Wagons(), a.Composition.Slope(), speed)
+	if err != nil {
+		a.ShowError(err)
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Booked %v on %g ‰\n", req.Speed, req.Slope.Permil())
+	switch {
+	case !req.Achievable:
+		b.WriteString("❌ The speed table allows no train this speed on this gradient.")
+	case req.Met():
+		fmt.Fprintf(&b, "✅ Required %d %%, the train has %d %%. Nothing is missing.", req.RequiredPercentage, req.CurrentPercentage)
+	default:
+		fmt.Fprintf(&b, "❌ Required %d %%, the train has %d %%.\nMissing brake weight: %v\n\nOptions (brake %% with that change alone):\n",
+			req.RequiredPercentage, req.CurrentPercentage, req.MissingBrakeWeight)
+		for _, s := range req.Suggestions {
+			mark := "•"
+			if s.Closes {
+				mark = "✅"
+			}
+			fmt.Fprintf(&b, "%s %s → %d %%\n", mark, s.Text, s.Percentage)
+		}
+	}
+
+	label := widget.NewLabel(b.String())
+	label.Wrapping = fyne.TextWrapWord
+	scroll := container.NewVScroll(label)
+	scroll.SetMinSize(fyne.NewSize(480, 320))
+	dialog.ShowCustom("Brake Requirement", "Close", scroll, a.MainWindow)
+}
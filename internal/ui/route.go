@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"fmt"
+	"railguard/internal/adapter/profile"
+	"railguard/internal/core/domain"
+	"railguard/internal/core/services"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+)
+
+// calculate runs the brake calculation of the working train. With a gradient
+// profile loaded it is computed section by section and the composition slope becomes the
+// steepest governing gradient; otherwise the slope entry is used as it is.
+func (a *App) calculate() (*domain.CalculationResult, *domain.Train, error) {
+	if a.CurrentProfile == nil {
+		res, train, err := a.Calculator.CalculateTrainParameters(a.Composition.Locomotives(), a.Composition.Wagons(), a.Composition.Slope())
+		if err != nil {
+			return nil, nil, err
+		}
+		a.attachBraking(train, res)
+		return res, train, nil
+	}
+
+	res, train, err := a.Calculator.CalculateRoute(a.Composition.Locomotives(), a.Composition.Wagons(), *a.CurrentProfile, a.CurrentRegime)
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.Braking != nil {
+		a.Composition.SetSlope(services.GoverningSlope(res.Braking.Gradient))
+	}
+	return res, train, nil
+}
+
+// showLoadProfileDialog reads a gradient profile from a CSV file of
+// "chainage_km,gradient_permil" lines.
+func (a *App) showLoadProfileDialog(onLoaded func()) {
+	fd := dialog.NewFileOpen(func(rc fyne.URIReadCloser, err error) {
+		if err != nil {
+			a.ShowError(err)
+			return
+		}
+		if rc == nil {
+			return // Cancelled
+		}
+		defer rc.Close()
+
+		name := strings.TrimSuffix(rc.URI().Name(), rc.URI().Extension())
+		p, err := profile.ReadCSV(rc, name)
+		if err != nil {
+			a.ShowError(fmt.Errorf("%s: %w", rc.URI().Name(), err))
+			return
+		}
+		a.CurrentProfile = p
+		onLoaded()
+	}, a.MainWindow)
+	fd.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	fd.Show()
+}
+
+// routeSummary lists the section speeds of a result computed over a profile
+func routeSummary(res *domain.CalculationResult) string {
+	if res == nil || len(res.Sections) == 0 {
+		return "Load a gradient profile (CSV: chainage_km,gradient_permil) to see section speeds."
+	}
+	lines := []string{fmt.Sprintf("Profile %s | %d sections", res.Profile, len(res.Sections))}
+	for _, sec := range res.Sections {
+		speed := fmt.Sprintf("%3.0f km/h", sec.MaxSpeed.KmPerHour())
+		if sec.MaxSpeed == 0 {
+			speed = "  STOP  "
+		}
+		lines = append(lines, fmt.Sprintf("%7.3f – %7.3f km | %+5.1f ‰ (governing %+5.1f ‰) | %s | stop %4.0f m",
+			sec.From.Kilometres(), sec.To.Kilometres(), sec.Gradient.Permil(), sec.Governing.Permil(), speed, sec.StoppingDistance.Metres()))
+	}
+	return strings.Join(lines, "\n")
+}
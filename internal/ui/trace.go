@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"fmt"
+	"railguard/internal/adapter/report"
+	"railguard/internal/core/domain"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// traceText lays the calculation trace out as a fixed-width table followed by the steps
+func traceText(trace *domain.CalculationTrace) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-4s %-22s %9s %9s  %s\n", "Pos", "Vehicle", "Weight t", "Brake t", "Reason")
+	for _, v := range trace.Vehicles {
+		fmt.Fprintf(&b, "%-4d %-22s %9.2f %9.2f  %s\n", v.Position, v.Label, v.Weight.Tonnes(), v.BrakeWeight.Tonnes(), v.Reason)
+	}
+	fmt.Fprintf(&b, "%-4s %-22s %9.2f %9.2f  length %.2f m, %d axles\n\n", "", "TOTAL", trace.TotalWeight.Tonnes(), trace.TotalBrake.Tonnes(), trace.TotalLength.Metres(), trace.AxleCount)
+	for i, step := range trace.Steps {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+	}
+	return b.String()
+}
+
+// showCalculationTrace shows how a result was reached, with the option to print it
+func (a *App) showCalculationTrace(res *domain.CalculationResult) {
+	if res.Trace == nil {
+		a.ShowInfo("Calculation Trace", "This result has no calculation trace.")
+		return
+	}
+	text := widget.NewLabel(traceText(res.Trace))
+	text.TextStyle = fyne.TextStyle{Monospace: true}
+	scroll := container.NewScroll(text)
+	scroll.SetMinSize(fyne.NewSize(640, 420))
+
+	printBtn := widget.NewButtonWithIcon("Print (PDF)", theme.DocumentPrintIcon(), func() {
+		name := fmt.Sprintf("CalculationTrace_%s.pdf", time.Now().Format("20060102_1504"))
+		a.saveFile(name, func(w fyne.URIWriteCloser) (string, error) {
+			err := report.NewPDFGenerator(nil).GenerateCalculationTrace(w, res)
+			if closeErr := w.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return "", err
+			}
+			return "Calculation trace saved as " + w.URI().Name(), nil
+		})
+	})
+
+	dialog.ShowCustom("Calculation Trace", "Close", container.NewBorder(nil, printBtn, nil, nil, scroll), a.MainWindow)
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+	"railguard/internal/core/domain"
+	"strings"
+)
+
+// tractionSummary is the traction part of the calculation result dialog
+func tractionSummary(t *domain.TractionResult) string {
+	if t == nil {
+		return "Traction: no locomotive in the composition"
+	}
+	lines := []string{}
+	if t.CanStart {
+		lines = append(lines, fmt.Sprintf("Traction: starts on %.1f ‰ (%.0f of %.0f kN), balancing speed %v",
+			t.RulingGradient.Permil(), t.StartingResistance, t.StartingEffort, t.BalancingSpeed))
+	} else {
+		lines = append(lines, fmt.Sprintf("Traction: ❌ cannot start on %.1f ‰ (%.0f kN needed, %.0f kN available)",
+			t.RulingGradient.Permil(), t.StartingResistance, t.StartingEffort))
+	}
+	lines = append(lines, fmt.Sprintf("Trailing load: %v", t.TrailingLoad))
+	for _, w := range t.Warnings {
+		lines = append(lines, "⚠️ "+w)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// couplerSummary is the coupler force part of the calculation result dialog
+func couplerSummary(c *domain.CouplerCheck) string {
+	if c == nil || len(c.Loads) == 0 {
+		return ""
+	}
+	line := fmt.Sprintf("Couplers: max %.0f kN, limit %.0f kN (%s)", c.MaxForce, c.Limit, c.WeakestType)
+	if len(c.AtRisk) > 0 {
+		var at []string
+		for _, l := range c.AtRisk {
+			at = append(at, l.Behind)
+		}
+		line += "\n❌ At risk of breaking in front of: " + strings.Join(at, ", ")
+	}
+	for _, t := range c.UnknownTypes {
+		line += fmt.Sprintf("\n⚠️ Coupling type %q unknown, checked as a screw coupling", t)
+	}
+	return line
+}
+
+// consistSummary lists the consist-order warnings with their fixes
+func consistSummary(warnings []domain.ConsistWarning) string {
+	if len(warnings) == 0 {
+		return ""
+	}
+	lines := []string{"Consist order (advisory):"}
+	for _, w := range warnings {
+		lines = append(lines, "⚠️ "+w.Text, "    → "+w.Fix)
+	}
+	return strings.Join(lines, "\n")
+}
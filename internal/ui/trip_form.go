@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"railguard/internal/core/domain"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showTripForm asks for the trip details used by SAVE, PDF LICENSE and history editing.
+// Empty date and time default to now.
+func (a *App) showTripForm(title, confirm string, trip domain.TripInfo, onSubmit func(info domain.TripInfo)) {
+	now := time.Now()
+	if trip.Date == "" {
+		trip.Date = now.Format("2006-01-02")
+	}
+	if trip.Time == "" {
+		trip.Time = now.Format("15:04")
+	}
+
+	newEntry := func(text, placeholder string) *widget.Entry {
+		e := widget.NewEntry()
+		e.SetText(text)
+		e.SetPlaceHolder(placeholder)
+		return e
+	}
+	tn := newEntry(trip.TrainNumber, "e.g. 4055")
+	dr := newEntry(trip.DriverName, "")
+	bs := newEntry(trip.TrainBossName, "")
+	org := newEntry(trip.Origin, "")
+	dst := newEntry(trip.Destination, "")
+	date := newEntry(trip.Date, "YYYY-MM-DD")
+	tm := newEntry(trip.Time, "HH:MM")
+
+	dialog.ShowForm(title, confirm, "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Train No:", tn), widget.NewFormItem("Driver:", dr),
+		widget.NewFormItem("Train Boss:", bs), widget.NewFormItem("Origin:", org), widget.NewFormItem("Dest:", dst),
+		widget.NewFormItem("Date:", date), widget.NewFormItem("Time:", tm),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		info := domain.TripInfo{
+			TrainNumber:   strings.TrimSpace(tn.Text),
+			DriverName:    strings.TrimSpace(dr.Text),
+			TrainBossName: strings.TrimSpace(bs.Text),
+			Origin:        strings.TrimSpace(org.Text),
+			Destination:   strings.TrimSpace(dst.Text),
+			Date:          strings.TrimSpace(date.Text),
+			Time:          strings.TrimSpace(tm.Text),
+		}
+		onSubmit(info)
+	}, a.MainWindow)
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"fmt"
+	"railguard/internal/adapter/storage/sqlite"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// wagonUsageLimit is how many recent trains the wagon lookup lists
+const wagonUsageLimit = 200
+
+// showWagonHistory shows in which saved trains a wagon ran and in what state.
+func (a *App) showWagonHistory(number int) {
+	repo, ok := a.WagonRepo.(*sqlite.WagonRepository)
+	if !ok {
+		return
+	}
+
+	stats, err := repo.GetWagonStats(number, wagonUsageLimit)
+	if err != nil {
+		a.ShowError(err)
+		return
+	}
+	if stats.TrainCount == 0 {
+		a.ShowInfo("Wagon History", fmt.Sprintf("Wagon #%d does not appear in any saved train.", number))
+		return
+	}
+
+	percent := func(n int) float64 { return float64(n) * 100 / float64(stats.TrainCount) }
+	summary := fmt.Sprintf(`[ WAGON #%d ]
+Trains: %d | Loaded: %d | Dangerous Goods: %d
+Air Brake unhealthy: %d (%.0f %%)
+Hand Brake unhealthy: %d | Brake Handle unhealthy: %d`,
+		number, stats.TrainCount, stats.LoadedCount, stats.DangerousCount,
+		stats.MainBrakeFaults, percent(stats.MainBrakeFaults), stats.HandBrakeFaults, stats.HandleFaults)
+
+	if last := stats.LastState(); last != nil {
+		summary += fmt.Sprintf("\n--------------------------------------\n[ LAST RECORDED STATE ]\nTrain #%s on %s, position %d\n%s",
+			last.TrainNumber, last.CreatedAt.Format("2006-01-02 15:04"), last.Position, wagonUsageState(*last))
+	}
+	if stats.UsageIsTruncated {
+		summary += fmt.Sprintf("\n(Showing the latest %d trains)", len(stats.Usage))
+	}
+
+	list := widget.NewList(
+		func() int { return len(stats.Usage) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("Train", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel("State"),
+			)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			u := stats.Usage[i]
+			box := o.(*fyne.Container)
+			box.Objects[0].(*widget.Label).SetText(fmt.Sprintf("Train #%s | %s | Position %d", u.TrainNumber, u.CreatedAt.Format("2006-01-02 15:04"), u.Position))
+			box.Objects[1].(*widget.Label).SetText(wagonUsageState(u))
+		},
+	)
+
+	content := container.NewBorder(container.NewVBox(widget.NewLabel(summary), widget.NewSeparator()), nil, nil, nil, list)
+	d := dialog.NewCustom("Wagon History", "Close", content, a.MainWindow)
+	d.Resize(fyne.NewSize(500, 600))
+	d.Show()
+}
+
+func wagonUsageState(u sqlite.WagonUsage) string {
+	ok := func(b bool) string {
+		if b {
+			return "OK"
+		}
+		return "FAULT"
+	}
+	load := "Empty"
+	if u.IsLoaded {
+		load = "Loaded"
+	}
+	state := fmt.Sprintf("%s | Air Brake: %s | Hand Brake: %s | Handle: %s", load, ok(u.IsMainBrakeHealthy), ok(u.IsHandBrakeHealthy), ok(u.IsBrakeHandleHealthy))
+	if u.HasDangerousGoods {
+		state += " | ⚠️ " + u.DangerousGoodsCode
+	}
+	return state
+}